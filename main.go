@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
@@ -19,8 +20,18 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
 
+	anthropicwrapper "github.com/imrany/wrapper/pkg/anthropic"
+	"github.com/imrany/wrapper/pkg/auth"
+	"github.com/imrany/wrapper/pkg/config"
+	geminiwrapper "github.com/imrany/wrapper/pkg/gemini"
+	ollamawrapper "github.com/imrany/wrapper/pkg/ollama"
+	openaiwrapper "github.com/imrany/wrapper/pkg/openai"
+	"github.com/imrany/wrapper/pkg/provider"
+	"github.com/imrany/wrapper/pkg/ratelimit"
 	pb "github.com/imrany/wrapper/proto/gen/api/v1"
 	apiv1 "github.com/imrany/wrapper/router/api/v1"
+	"github.com/imrany/wrapper/router/gateway"
+	"github.com/imrany/wrapper/router/openaicompat"
 )
 
 var rootCmd = &cobra.Command{
@@ -29,12 +40,27 @@ var rootCmd = &cobra.Command{
 	Run:   runServer,
 }
 
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Mint an HMAC token for a client, signed with auth.hmac_secret",
+	RunE:  runToken,
+}
+
 var logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
 
 func runServer(_ *cobra.Command, _ []string) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if path := viper.GetString("config"); path != "" {
+		viper.SetConfigFile(path)
+	}
+	cfg, err := config.Load(viper.GetViper())
+	if err != nil {
+		logger.Error("Failed to load config", "error", err)
+		return
+	}
+
 	port := viper.GetInt("port")
 	if port == 0 {
 		port = 8080
@@ -58,12 +84,110 @@ func runServer(_ *cobra.Command, _ []string) {
 		return
 	}
 
+	// Token estimates of CountTokens must not change once the tokenizers
+	// are downloaded, so they are loaded before serving
+	if err := provider.LoadTokenizers(); err != nil {
+		logger.Error("Failed to load tokenizers", "error", err)
+		return
+	}
+
+	// Provider clients are created once and shared by all requests
+	httpClient := provider.NewHTTPClient()
+	providers := provider.NewRegistry()
+
+	geminiConfig := cfg.Providers.Gemini
+	if geminiConfig.APIKey == "" && !geminiConfig.UseVertexAI() {
+		geminiConfig.APIKey = apiKey
+	}
+	geminiConfig.HTTPClient = httpClient
+	gemini, err := geminiwrapper.NewGeminiClient(ctx, geminiConfig)
+	if err != nil {
+		logger.Warn("Gemini provider disabled", "error", err)
+	} else if err := providers.Register(gemini, "gemini-", "gemma-"); err != nil {
+		logger.Error("Failed to register provider", "error", err)
+		return
+	}
+
+	openaiConfig := cfg.Providers.OpenAI
+	if openaiConfig.APIKey == "" {
+		openaiConfig.APIKey = apiKey
+	}
+	openaiConfig.HTTPClient = httpClient
+	openai := openaiwrapper.NewOpenAIClient(openaiConfig)
+	if err := providers.Register(openai, "gpt-", "chatgpt-", "ft:gpt-", "o1", "o3", "o4", "text-embedding-"); err != nil {
+		logger.Error("Failed to register provider", "error", err)
+		return
+	}
+
+	anthropicConfig := cfg.Providers.Anthropic
+	if anthropicConfig.APIKey == "" {
+		anthropicConfig.APIKey = apiKey
+	}
+	anthropicConfig.HTTPClient = httpClient
+	anthropic := anthropicwrapper.NewAnthropicClient(anthropicConfig)
+	if err := providers.Register(anthropic, "claude-"); err != nil {
+		logger.Error("Failed to register provider", "error", err)
+		return
+	}
+
+	ollamaConfig := cfg.Providers.Ollama
+	ollamaConfig.HTTPClient = httpClient
+	ollama := ollamawrapper.NewOllamaClient(ollamaConfig)
+	if err := providers.Register(ollama); err != nil {
+		logger.Error("Failed to register provider", "error", err)
+		return
+	}
+
+	// Routes and aliases may point at a disabled provider, which is not
+	// fatal like the provider itself.
+	for _, r := range cfg.Routing.Prefixes {
+		if err := providers.Route(r.Prefix, r.Provider); err != nil {
+			logger.Warn("Route ignored", "error", err)
+		}
+	}
+	for _, a := range cfg.Routing.Aliases {
+		if err := providers.Alias(a.Name, a.Model); err != nil {
+			logger.Warn("Alias ignored", "error", err)
+		}
+	}
+
 	// Create gRPC server
-	grpcServer := grpc.NewServer()
+	var unary []grpc.UnaryServerInterceptor
+	var streams []grpc.StreamServerInterceptor
+	if cfg.Auth.Enabled() {
+		authenticator, err := auth.New(cfg.Auth)
+		if err != nil {
+			logger.Error("Failed to set up authentication", "error", err)
+			return
+		}
+		// The gateway and the OpenAI-compatible endpoint forward the
+		// Authorization header, so this protects every endpoint.
+		unary = append(unary, auth.UnaryServerInterceptor(authenticator))
+		streams = append(streams, auth.StreamServerInterceptor(authenticator))
+	} else {
+		logger.Warn("Authentication disabled, anyone who can reach the server can use it")
+	}
+	// Rate limits run after authentication to limit by client name.
+	if cfg.RateLimits.Enabled() {
+		limiter, err := ratelimit.New(cfg.RateLimits)
+		if err != nil {
+			logger.Error("Failed to set up rate limits", "error", err)
+			return
+		}
+		unary = append(unary, ratelimit.UnaryServerInterceptor(limiter))
+		streams = append(streams, ratelimit.StreamServerInterceptor(limiter))
+	}
+	grpcServer := grpc.NewServer(
+		// Leave room for inline images and documents besides the request text
+		grpc.MaxRecvMsgSize(2*apiv1.MaxInlineBytes),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(streams...),
+	)
 	pb.RegisterAiServiceServer(grpcServer, &apiv1.APIV1Service{
-		Logger: logger,
-		APIKey: apiKey,
-		Model:  model,
+		Logger:    logger,
+		Providers: providers,
+		Config:    cfg,
+		Model:     model,
 	})
 	reflection.Register(grpcServer)
 
@@ -77,21 +201,37 @@ func runServer(_ *cobra.Command, _ []string) {
 
 	// Setup REST gateway
 	mux := http.NewServeMux()
-	gw := runtime.NewServeMux()
-	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	gw := runtime.NewServeMux(
+		runtime.WithMarshalerOption(gateway.EventStreamMIME, gateway.NewSSEMarshaler()),
+		runtime.WithOutgoingHeaderMatcher(gateway.OutgoingHeaderMatcher),
+	)
+	dialOpts := gatewayDialOptions()
 
 	if err := pb.RegisterAiServiceHandlerFromEndpoint(ctx, gw, addr, dialOpts); err != nil {
 		logger.Error("Failed to register gateway", "error", err)
 		return
 	}
 
-	mux.Handle("/", gw)
+	conn, err := grpc.NewClient(addr, dialOpts...)
+	if err != nil {
+		logger.Error("Failed to connect to gRPC server", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	chat := &openaicompat.Handler{
+		Client: pb.NewAiServiceClient(conn),
+		Logger: logger,
+	}
+
+	mux.Handle("/", withMaxBody(gateway.WithEventStream(gw), 2*apiv1.MaxInlineBytes))
+	mux.HandleFunc("/v1/chat/completions", chat.ChatCompletions)
 	mux.Handle("/swagger/", http.StripPrefix("/swagger/", http.FileServer(http.Dir("proto/gen/api/v1"))))
 
 	// Create HTTP server with proper shutdown support
 	httpServer := &http.Server{
 		Addr:    "0.0.0.0:8090",
-		Handler: withLogging(withCORS(mux)),
+		Handler: withLogging(withCORS(mux, cfg.CORSOrigins)),
 	}
 
 	// Start HTTP server in a goroutine
@@ -125,9 +265,49 @@ func runServer(_ *cobra.Command, _ []string) {
 	logger.Info("gRPC server stopped gracefully")
 }
 
-func withCORS(h http.Handler) http.Handler {
+func runToken(cmd *cobra.Command, _ []string) error {
+	if path := viper.GetString("config"); path != "" {
+		viper.SetConfigFile(path)
+	}
+	cfg, err := config.Load(viper.GetViper())
+	if err != nil {
+		return err
+	}
+	if cfg.Auth.HMACSecret == "" {
+		return fmt.Errorf("auth.hmac_secret is not set in the config file")
+	}
+
+	client, _ := cmd.Flags().GetString("client")
+	ttl, _ := cmd.Flags().GetDuration("ttl")
+	token, err := auth.NewHMAC([]byte(cfg.Auth.HMACSecret)).Token(client, time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+	fmt.Println(token)
+	return nil
+}
+
+// withCORS allows browsers on origins to call h, any origin when empty.
+// gatewayDialOptions are the options of the REST gateway's connections to
+// the gRPC server. Embedding batches are far larger than gRPC's default 4MB
+// receive limit.
+func gatewayDialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(apiv1.MaxEmbedResponseBytes)),
+	}
+}
+
+func withCORS(h http.Handler, origins []string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if len(origins) == 0 {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); slices.Contains(origins, origin) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
@@ -151,6 +331,15 @@ func withLogging(h http.Handler) http.Handler {
 	})
 }
 
+// withMaxBody stops reading request bodies after n bytes, matching the
+// receive limit of the gRPC server the gateway forwards to.
+func withMaxBody(h http.Handler, n int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, n)
+		h.ServeHTTP(w, r)
+	})
+}
+
 func init() {
 	if err := godotenv.Load(); err != nil {
 		logger.Warn("No .env file found or failed to load")
@@ -162,11 +351,17 @@ func init() {
 		"port":    "PORT",
 		"api-key": "API_KEY",
 		"model":   "MODEL",
+		"config":  "CONFIG",
 	}
 
 	rootCmd.PersistentFlags().Int("port", 8080, "Port to run the gRPC server on")
 	rootCmd.PersistentFlags().String("api-key", "", "API key, e.g Gemini API Key")
 	rootCmd.PersistentFlags().String("model", "", "Model, e.g Gemini API Model")
+	rootCmd.PersistentFlags().String("config", "", "Path to a YAML config file, e.g config.yaml")
+
+	tokenCmd.Flags().String("client", "", "Client name the token identifies")
+	tokenCmd.Flags().Duration("ttl", 30*24*time.Hour, "How long the token is valid")
+	rootCmd.AddCommand(tokenCmd)
 
 	for key, env := range envBindings {
 		if err := viper.BindEnv(key, env); err != nil {
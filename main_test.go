@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+
+	pb "github.com/imrany/wrapper/proto/gen/api/v1"
+)
+
+// embedServer answers every Embed call with vectors of dimensions floats.
+type embedServer struct {
+	pb.UnimplementedAiServiceServer
+	dimensions int
+}
+
+func (s *embedServer) Embed(_ context.Context, req *pb.EmbedRequest) (*pb.EmbedResponse, error) {
+	resp := &pb.EmbedResponse{Model: req.Model}
+	for range req.Inputs {
+		values := make([]float32, s.dimensions)
+		for i := range values {
+			values[i] = 0.5
+		}
+		resp.Embeddings = append(resp.Embeddings, &pb.Embedding{Values: values})
+	}
+	return resp, nil
+}
+
+func TestGatewayLargeEmbedBatch(t *testing.T) {
+	const inputs, dimensions = 2048, 3072
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterAiServiceServer(server, &embedServer{dimensions: dimensions})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	gw := runtime.NewServeMux()
+	if err := pb.RegisterAiServiceHandlerFromEndpoint(ctx, gw, lis.Addr().String(), gatewayDialOptions()); err != nil {
+		t.Fatal(err)
+	}
+	rest := httptest.NewServer(gw)
+	defer rest.Close()
+
+	texts := make([]string, inputs)
+	for i := range texts {
+		texts[i] = "document"
+	}
+	body, _ := json.Marshal(map[string]any{"inputs": texts, "model": "text-embedding-3-large"})
+	resp, err := http.Post(rest.URL+"/v1/embeddings", "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var got struct {
+		Embeddings []struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, got.Message)
+	}
+	if len(got.Embeddings) != inputs || len(got.Embeddings[inputs-1].Values) != dimensions {
+		t.Fatalf("got %d embeddings, want %d of %d dimensions", len(got.Embeddings), inputs, dimensions)
+	}
+}
+
+func TestGatewayBodyLimit(t *testing.T) {
+	gw := runtime.NewServeMux()
+	if err := pb.RegisterAiServiceHandlerServer(context.Background(), gw, &embedServer{dimensions: 1}); err != nil {
+		t.Fatal(err)
+	}
+	rest := httptest.NewServer(withMaxBody(gw, 1024))
+	defer rest.Close()
+
+	post := func(text string) int {
+		body, _ := json.Marshal(map[string]any{"inputs": []string{text}})
+		resp, err := http.Post(rest.URL+"/v1/embeddings", "application/json", strings.NewReader(string(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if got := post("document"); got != http.StatusOK {
+		t.Fatalf("small body: status = %d, want %d", got, http.StatusOK)
+	}
+	if got := post(strings.Repeat("x", 2048)); got != http.StatusBadRequest {
+		t.Fatalf("large body: status = %d, want %d", got, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,340 @@
+package anthropicwrapper
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/imrany/wrapper/pkg/chat"
+	"github.com/imrany/wrapper/pkg/provider"
+)
+
+// defaultMaxTokens is used when neither the request nor the config sets a
+// limit; the Messages API requires max_tokens on every request.
+const defaultMaxTokens = 4096
+
+// AnthropicClientConfig configures an AnthropicClient.
+type AnthropicClientConfig struct {
+	APIKey string `mapstructure:"api_key"`
+	// BaseURL defaults to https://api.anthropic.com.
+	BaseURL string `mapstructure:"base_url"`
+	// MaxTokens is sent when the request has no max_output_tokens,
+	// defaults to 4096.
+	MaxTokens int64 `mapstructure:"max_tokens"`
+	// HTTPClient is shared by all requests, defaults to provider.NewHTTPClient.
+	HTTPClient *http.Client `mapstructure:"-"`
+}
+
+// AnthropicClient is the Anthropic Messages API implementation of
+// provider.Provider. It is safe for concurrent use.
+type AnthropicClient struct {
+	client    anthropic.Client
+	maxTokens int64
+}
+
+var _ provider.Provider = (*AnthropicClient)(nil)
+
+// NewAnthropicClient creates the long-lived Anthropic client, call it once at
+// startup.
+func NewAnthropicClient(config AnthropicClientConfig) *AnthropicClient {
+	if config.HTTPClient == nil {
+		config.HTTPClient = provider.NewHTTPClient()
+	}
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = defaultMaxTokens
+	}
+
+	// Failed calls are retried by the wrapper's own retry policy.
+	opts := []option.RequestOption{
+		option.WithAPIKey(config.APIKey),
+		option.WithHTTPClient(config.HTTPClient),
+		option.WithMaxRetries(0),
+	}
+	if config.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(config.BaseURL))
+	}
+	return &AnthropicClient{
+		client:    anthropic.NewClient(opts...),
+		maxTokens: config.MaxTokens,
+	}
+}
+
+func (a *AnthropicClient) Name() string {
+	return "anthropic"
+}
+
+func (a *AnthropicClient) Generate(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	params, err := a.toMessageParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := a.client.Messages.New(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return toResponse(message)
+}
+
+func (a *AnthropicClient) Stream(ctx context.Context, req *provider.Request, onChunk func(string) error) (*provider.Response, error) {
+	params, err := a.toMessageParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := a.client.Messages.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	message := anthropic.Message{}
+	for stream.Next() {
+		event := stream.Current()
+		if err := message.Accumulate(event); err != nil {
+			return nil, err
+		}
+
+		delta, ok := event.AsAny().(anthropic.ContentBlockDeltaEvent)
+		if !ok {
+			continue
+		}
+		if text, ok := delta.Delta.AsAny().(anthropic.TextDelta); ok && text.Text != "" {
+			if err := onChunk(text.Text); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+
+	return toResponse(&message)
+}
+
+func (a *AnthropicClient) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	var models []provider.ModelInfo
+	pager := a.client.Models.ListAutoPaging(ctx, anthropic.ModelListParams{})
+	for pager.Next() {
+		m := pager.Current()
+		models = append(models, provider.ModelInfo{
+			ID:          m.ID,
+			Provider:    a.Name(),
+			DisplayName: m.DisplayName,
+		})
+	}
+	if err := pager.Err(); err != nil {
+		return nil, err
+	}
+	return models, nil
+}
+
+// Capabilities reports the features of Claude models. Every current Claude
+// model accepts images and tools; there is no JSON mode.
+func (a *AnthropicClient) Capabilities(_ string) provider.Capabilities {
+	return provider.Capabilities{
+		Streaming: true,
+		Vision:    true,
+		Tools:     true,
+	}
+}
+
+// Retryable reports whether err is a rate limit, overload, server error or
+// network failure.
+func (a *AnthropicClient) Retryable(err error) bool {
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		return provider.RetryableStatus(apiErr.StatusCode)
+	}
+	return provider.IsTransient(err)
+}
+
+// toMessageParams maps req to Messages API parameters. System messages are
+// moved into the top-level system prompt, followed by the instruction for
+// any response format; seeds are not supported.
+func (a *AnthropicClient) toMessageParams(req *provider.Request) (anthropic.MessageNewParams, error) {
+	params := anthropic.MessageNewParams{
+		Model:         anthropic.Model(req.Model),
+		MaxTokens:     a.maxTokens,
+		StopSequences: req.Generation.StopSequences,
+	}
+
+	gen := req.Generation
+	if gen.MaxOutputTokens != nil {
+		params.MaxTokens = int64(*gen.MaxOutputTokens)
+	}
+	if gen.Temperature != nil {
+		params.Temperature = anthropic.Float(float64(*gen.Temperature))
+	}
+	if gen.TopP != nil {
+		params.TopP = anthropic.Float(float64(*gen.TopP))
+	}
+
+	for _, tool := range req.Tools {
+		params.Tools = append(params.Tools, anthropic.ToolUnionParam{OfTool: toToolParam(tool)})
+	}
+
+	if req.SystemInstruction != "" {
+		params.System = append(params.System, anthropic.TextBlockParam{Text: req.SystemInstruction})
+	}
+	for _, m := range req.Messages {
+		switch m.Role {
+		case chat.RoleSystem:
+			params.System = append(params.System, anthropic.TextBlockParam{Text: m.Content})
+		case chat.RoleAssistant:
+			blocks, err := toContentBlocks(m)
+			if err != nil {
+				return params, err
+			}
+			params.Messages = append(params.Messages, anthropic.NewAssistantMessage(blocks...))
+		case chat.RoleTool:
+			params.Messages = append(params.Messages, anthropic.NewUserMessage(anthropic.NewToolResultBlock(m.ToolCallID, m.Content, false)))
+		default:
+			blocks, err := toContentBlocks(m)
+			if err != nil {
+				return params, err
+			}
+			params.Messages = append(params.Messages, anthropic.NewUserMessage(blocks...))
+		}
+	}
+
+	if req.ResponseFormat != nil {
+		instruction, err := jsonInstruction(req.ResponseFormat)
+		if err != nil {
+			return params, err
+		}
+		params.System = append(params.System, anthropic.TextBlockParam{Text: instruction})
+	}
+	return params, nil
+}
+
+// jsonInstruction asks for JSON output in the system prompt, as the Messages
+// API has no JSON mode.
+func jsonInstruction(format *provider.ResponseFormat) (string, error) {
+	instruction := "Respond with a single JSON object only, without code fences or any other text."
+	if format.Type != provider.ResponseJSONSchema {
+		return instruction, nil
+	}
+	schema, err := json.Marshal(format.Schema)
+	if err != nil {
+		return "", fmt.Errorf("invalid response schema: %w", err)
+	}
+	return instruction + " The object must match this JSON Schema:\n" + string(schema), nil
+}
+
+// toToolParam splits the JSON Schema of tool into the properties and
+// required fields of the Messages API, passing any other keywords through.
+func toToolParam(tool chat.Tool) *anthropic.ToolParam {
+	param := &anthropic.ToolParam{Name: tool.Name}
+	if tool.Description != "" {
+		param.Description = anthropic.String(tool.Description)
+	}
+
+	extra := make(map[string]any)
+	for key, value := range tool.Parameters {
+		switch key {
+		case "type":
+		case "properties":
+			param.InputSchema.Properties = value
+		case "required":
+			switch values := value.(type) {
+			case []string:
+				param.InputSchema.Required = values
+			case []any:
+				for _, v := range values {
+					if name, ok := v.(string); ok {
+						param.InputSchema.Required = append(param.InputSchema.Required, name)
+					}
+				}
+			}
+		default:
+			extra[key] = value
+		}
+	}
+	if len(extra) > 0 {
+		param.InputSchema.ExtraFields = extra
+	}
+	return param
+}
+
+// toContentBlocks maps the text, images, PDFs and tool calls of m to content
+// blocks.
+func toContentBlocks(m chat.Message) ([]anthropic.ContentBlockParamUnion, error) {
+	blocks := make([]anthropic.ContentBlockParamUnion, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		blocks = append(blocks, anthropic.NewTextBlock(m.Content))
+	}
+	for _, part := range m.Parts {
+		switch {
+		case part.IsImage() && part.URL != "":
+			blocks = append(blocks, anthropic.NewImageBlock(anthropic.URLImageSourceParam{URL: part.URL}))
+		case part.IsImage():
+			blocks = append(blocks, anthropic.NewImageBlockBase64(part.MIMEType, base64.StdEncoding.EncodeToString(part.Data)))
+		case part.MIMEType == "application/pdf" && part.URL != "":
+			blocks = append(blocks, anthropic.NewDocumentBlock(anthropic.URLPDFSourceParam{URL: part.URL}))
+		case part.MIMEType == "application/pdf":
+			blocks = append(blocks, anthropic.NewDocumentBlock(anthropic.Base64PDFSourceParam{
+				Data: base64.StdEncoding.EncodeToString(part.Data),
+			}))
+		default:
+			return nil, fmt.Errorf("%w: anthropic accepts images and PDFs only, got %s", provider.ErrUnsupportedContent, part.MIMEType)
+		}
+	}
+	for _, call := range m.ToolCalls {
+		args := call.Arguments
+		if args == nil {
+			args = map[string]any{}
+		}
+		blocks = append(blocks, anthropic.NewToolUseBlock(call.ID, args, call.Name))
+	}
+	return blocks, nil
+}
+
+func toResponse(message *anthropic.Message) (*provider.Response, error) {
+	var text strings.Builder
+	var toolCalls []chat.ToolCall
+	for _, block := range message.Content {
+		switch block := block.AsAny().(type) {
+		case anthropic.TextBlock:
+			text.WriteString(block.Text)
+		case anthropic.ToolUseBlock:
+			var args map[string]any
+			if err := json.Unmarshal(block.Input, &args); err != nil {
+				return nil, fmt.Errorf("tool call %s: invalid arguments: %w", block.Name, err)
+			}
+			toolCalls = append(toolCalls, chat.ToolCall{ID: block.ID, Name: block.Name, Arguments: args})
+		}
+	}
+
+	usage := message.Usage
+	prompt := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	return &provider.Response{
+		Text:         text.String(),
+		ToolCalls:    toolCalls,
+		FinishReason: finishReason(message.StopReason),
+		Usage: provider.Usage{
+			PromptTokens:     int32(prompt),
+			CompletionTokens: int32(usage.OutputTokens),
+			TotalTokens:      int32(prompt + usage.OutputTokens),
+		},
+		ModelVersion: string(message.Model),
+	}, nil
+}
+
+func finishReason(reason anthropic.StopReason) provider.FinishReason {
+	switch reason {
+	case anthropic.StopReasonEndTurn, anthropic.StopReasonStopSequence:
+		return provider.FinishStop
+	case anthropic.StopReasonMaxTokens:
+		return provider.FinishLength
+	case anthropic.StopReasonToolUse:
+		return provider.FinishToolCalls
+	case anthropic.StopReasonRefusal:
+		return provider.FinishSafety
+	default:
+		return provider.FinishOther
+	}
+}
@@ -0,0 +1,115 @@
+// Package auth authenticates the clients of the wrapper. Clients send a
+// bearer token that is checked against static API keys, HMAC-signed tokens
+// or JWTs, whichever are configured.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrInvalidCredentials is returned for a token no authenticator accepts.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// clientID is the format of client names in keys files and HMAC tokens.
+var clientID = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Principal is an authenticated client.
+type Principal struct {
+	// Client identifies the caller, e.g in logs and rate limits.
+	Client string
+	// Method is how the client authenticated: "key", "hmac" or "jwt".
+	Method string
+}
+
+// Authenticator checks a bearer token. It returns nil and no error for a
+// token it does not recognise, so the next authenticator can try it.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+// Chain tries each authenticator in order.
+type Chain []Authenticator
+
+// Authenticate returns the principal of the first authenticator that
+// recognises token.
+func (c Chain) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			return p, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// Config holds the inbound authentication settings. Authentication is
+// disabled when none of the methods is configured.
+type Config struct {
+	// KeysFile lists static API keys, one "client key" pair per line.
+	KeysFile string `mapstructure:"keys_file"`
+
+	// HMACSecret verifies tokens minted with `wrapper token`.
+	HMACSecret string `mapstructure:"hmac_secret"`
+
+	// JWT verifies JSON Web Tokens issued by an identity provider.
+	JWT JWTConfig `mapstructure:"jwt"`
+}
+
+// JWTConfig holds the settings of JWT authentication.
+type JWTConfig struct {
+	// JWKSFile is a JSON Web Key Set with the keys of the issuer.
+	JWKSFile string `mapstructure:"jwks_file"`
+	// Issuer and Audience must match the iss and aud claims, if set.
+	Issuer   string `mapstructure:"issuer"`
+	Audience string `mapstructure:"audience"`
+}
+
+// Enabled reports whether any authentication method is configured.
+func (c Config) Enabled() bool {
+	return c.KeysFile != "" || c.HMACSecret != "" || c.JWT.JWKSFile != ""
+}
+
+// New returns a Chain of the configured authenticators.
+func New(config Config) (Chain, error) {
+	var chain Chain
+	if config.KeysFile != "" {
+		keys, err := LoadKeys(config.KeysFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, keys)
+	}
+	if config.HMACSecret != "" {
+		chain = append(chain, NewHMAC([]byte(config.HMACSecret)))
+	}
+	if config.JWT.JWKSFile != "" {
+		jwt, err := LoadJWT(config.JWT)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, jwt)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no authentication method configured")
+	}
+	return chain, nil
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of the request, if authenticated.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestHMAC(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	h := NewHMAC([]byte("secret"))
+	h.now = func() time.Time { return now }
+
+	valid, err := h.Token("acme", now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := h.Token("acme", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewHMAC([]byte("other")).Token("acme", now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := cutLast(valid, ".")
+	_, expires, _ := strings.Cut(payload, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		client  string
+		wantErr bool
+	}{
+		{name: "valid", token: valid, client: "acme"},
+		{name: "expired", token: expired, wantErr: true},
+		{name: "other secret", token: other, wantErr: true},
+		{name: "tampered client", token: "beta." + expires + "." + signature, wantErr: true},
+		{name: "tampered expiry", token: "acme.9999999999." + signature, wantErr: true},
+		{name: "api key", token: "sk-acme-123"},
+		{name: "jwt", token: "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiJhY21lIn0.c2ln"},
+		{name: "short signature", token: "acme." + expires + ".abcd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := h.Authenticate(context.Background(), tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := clientOf(p); got != tt.client {
+				t.Fatalf("client = %q, want %q", got, tt.client)
+			}
+		})
+	}
+}
+
+func TestHMACTokenClient(t *testing.T) {
+	h := NewHMAC([]byte("secret"))
+	for _, client := range []string{"", "a.b", "a b", strings.Repeat("a", 65)} {
+		if _, err := h.Token(client, time.Now()); err == nil {
+			t.Errorf("Token(%q) succeeded, want an error", client)
+		}
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+	}{
+		{name: "valid", file: "# clients\n\nacme sk-acme-123\n  beta\tsk-beta-456  \n"},
+		{name: "missing key", file: "acme\n", wantErr: ":1: expected a client name and a key"},
+		{name: "extra field", file: "acme sk-acme-123 x\n", wantErr: ":1: expected a client name and a key"},
+		{name: "bad client", file: "ac.me sk-acme-123\n", wantErr: ":1: client name"},
+		{name: "duplicate key", file: "acme sk-1\nbeta sk-1\n", wantErr: ":2: duplicate key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := LoadKeys(writeFile(t, tt.file))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for token, client := range map[string]string{"sk-acme-123": "acme", "sk-beta-456": "beta", "sk-acme-12": "", "acme": ""} {
+				p, err := keys.Authenticate(context.Background(), token)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := clientOf(p); got != client {
+					t.Errorf("Authenticate(%q) client = %q, want %q", token, got, client)
+				}
+			}
+		})
+	}
+
+	if _, err := LoadKeys(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadKeys of a missing file succeeded")
+	}
+}
+
+func TestJWT(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, stranger, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := `{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"k1","alg":"EdDSA","use":"sig","x":"` +
+		base64.RawURLEncoding.EncodeToString(public) + `"}]}`
+	j, err := LoadJWT(JWTConfig{JWKSFile: writeFile(t, jwks), Issuer: "https://idp.example", Audience: "wrapper"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(modify func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub": "acme",
+			"iss": "https://idp.example",
+			"aud": "wrapper",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+	sign := func(method jwt.SigningMethod, key any, c jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, c)
+		token.Header["kid"] = "k1"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		client  string
+		wantErr bool
+	}{
+		{name: "valid", token: sign(jwt.SigningMethodEdDSA, private, claims(nil)), client: "acme"},
+		{name: "unknown key", token: sign(jwt.SigningMethodEdDSA, stranger, claims(nil)), wantErr: true},
+		{name: "hmac alg", token: sign(jwt.SigningMethodHS256, []byte("secret"), claims(nil)), wantErr: true},
+		{name: "none alg", token: sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims(nil)), wantErr: true},
+		{name: "expired", token: sign(jwt.SigningMethodEdDSA, private, claims(func(c jwt.MapClaims) {
+			c["exp"] = time.Now().Add(-time.Minute).Unix()
+		})), wantErr: true},
+		{name: "no expiry", token: sign(jwt.SigningMethodEdDSA, private, claims(func(c jwt.MapClaims) {
+			delete(c, "exp")
+		})), wantErr: true},
+		{name: "wrong issuer", token: sign(jwt.SigningMethodEdDSA, private, claims(func(c jwt.MapClaims) {
+			c["iss"] = "https://evil.example"
+		})), wantErr: true},
+		{name: "wrong audience", token: sign(jwt.SigningMethodEdDSA, private, claims(func(c jwt.MapClaims) {
+			c["aud"] = "other"
+		})), wantErr: true},
+		{name: "no subject", token: sign(jwt.SigningMethodEdDSA, private, claims(func(c jwt.MapClaims) {
+			delete(c, "sub")
+		})), wantErr: true},
+		{name: "api key", token: "sk-acme-123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := j.Authenticate(context.Background(), tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := clientOf(p); got != tt.client {
+				t.Fatalf("client = %q, want %q", got, tt.client)
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	keys, err := LoadKeys(writeFile(t, "acme sk-acme-123\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHMAC([]byte("secret"))
+	token, err := h.Token("beta", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := Chain{keys, h}
+
+	for token, want := range map[string]string{"sk-acme-123": "key", token: "hmac"} {
+		p, err := chain.Authenticate(context.Background(), token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Method != want {
+			t.Errorf("method = %q, want %q", p.Method, want)
+		}
+	}
+	if _, err := chain.Authenticate(context.Background(), "sk-unknown"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown token: err = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	keys, err := LoadKeys(writeFile(t, "acme sk-acme-123\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor := UnaryServerInterceptor(Chain{keys})
+	handler := func(ctx context.Context, _ any) (any, error) {
+		p, ok := FromContext(ctx)
+		if !ok {
+			return nil, errors.New("no principal")
+		}
+		return p.Client, nil
+	}
+
+	tests := []struct {
+		name   string
+		header []string
+		want   codes.Code
+	}{
+		{name: "bearer", header: []string{"Bearer sk-acme-123"}, want: codes.OK},
+		{name: "lowercase scheme", header: []string{"bearer sk-acme-123"}, want: codes.OK},
+		{name: "missing", want: codes.Unauthenticated},
+		{name: "basic", header: []string{"Basic YWNtZTpzay1hY21lLTEyMw=="}, want: codes.Unauthenticated},
+		{name: "no scheme", header: []string{"sk-acme-123"}, want: codes.Unauthenticated},
+		{name: "empty token", header: []string{"Bearer "}, want: codes.Unauthenticated},
+		{name: "unknown key", header: []string{"Bearer sk-nope"}, want: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.header != nil {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.header[0]))
+			}
+			resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %v, want %v (err %v)", got, tt.want, err)
+			}
+			if tt.want == codes.OK && resp != "acme" {
+				t.Fatalf("client = %v, want acme", resp)
+			}
+		})
+	}
+}
+
+func clientOf(p *Principal) string {
+	if p == nil {
+		return ""
+	}
+	return p.Client
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWT authenticates JSON Web Tokens signed by a key of a JWKS. The sub claim
+// identifies the client.
+type JWT struct {
+	keys   keyfunc.Keyfunc
+	parser *jwt.Parser
+}
+
+// LoadJWT reads the JWKS file of config. Only asymmetric signing methods are
+// accepted; shared secrets belong in hmac_secret.
+func LoadJWT(config JWTConfig) (*JWT, error) {
+	raw, err := os.ReadFile(config.JWKSFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks file: %w", err)
+	}
+	keys, err := keyfunc.NewJWKSetJSON(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwks file: %w", err)
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	return &JWT{keys: keys, parser: jwt.NewParser(options...)}, nil
+}
+
+// Authenticate verifies token, returning nil if it is not a JWT.
+func (j *JWT) Authenticate(_ context.Context, token string) (*Principal, error) {
+	parsed, err := j.parser.Parse(token, j.keys.Keyfunc)
+	if errors.Is(err, jwt.ErrTokenMalformed) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	subject, err := parsed.Claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+	return &Principal{Client: subject, Method: "jwt"}, nil
+}
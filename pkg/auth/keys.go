@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Keys authenticates static API keys. Keys are held as SHA-256 digests, so
+// lookups do not depend on how much of a key matches.
+type Keys struct {
+	clients map[[sha256.Size]byte]string
+}
+
+// LoadKeys reads a keys file. Each line holds a client name and its key
+// separated by whitespace; blank lines and lines starting with # are
+// skipped.
+func LoadKeys(path string) (*Keys, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open keys file: %w", err)
+	}
+	defer f.Close()
+
+	keys := &Keys{clients: make(map[[sha256.Size]byte]string)}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected a client name and a key", path, n)
+		}
+		client, key := fields[0], fields[1]
+		if !clientID.MatchString(client) {
+			return nil, fmt.Errorf("%s:%d: client name must be 1-64 letters, digits, underscores or dashes", path, n)
+		}
+		digest := sha256.Sum256([]byte(key))
+		if _, ok := keys.clients[digest]; ok {
+			return nil, fmt.Errorf("%s:%d: duplicate key", path, n)
+		}
+		keys.clients[digest] = client
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read keys file: %w", err)
+	}
+	return keys, nil
+}
+
+// Authenticate returns the client of key, or nil if it is not listed.
+func (k *Keys) Authenticate(_ context.Context, key string) (*Principal, error) {
+	client, ok := k.clients[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, nil
+	}
+	return &Principal{Client: client, Method: "key"}, nil
+}
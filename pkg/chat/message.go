@@ -0,0 +1,92 @@
+package chat
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Role identifies the author of a Message.
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	// RoleTool messages carry the result of a tool call in Content.
+	RoleTool Role = "tool"
+)
+
+// Message is a single provider-neutral turn of a conversation. Parts carry
+// media such as images or PDFs and follow the text Content.
+type Message struct {
+	Role    Role
+	Content string
+	Parts   []Part
+	// ToolCalls are the calls requested by an assistant message.
+	ToolCalls []ToolCall
+	// ToolCallID is the call a tool message answers.
+	ToolCallID string
+}
+
+// Tool declares a function the model may call.
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON Schema of the arguments object.
+	Parameters map[string]any
+}
+
+// ToolCall is a request by the model to call a tool.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments map[string]any
+}
+
+// NewToolCallID returns a unique tool call ID, for providers that do not
+// assign their own.
+func NewToolCallID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "call_" + hex.EncodeToString(b)
+}
+
+// ToolName returns the name of the tool called by id in messages, for
+// providers that match tool results by name rather than ID.
+func ToolName(messages []Message, id string) string {
+	for _, m := range messages {
+		for _, call := range m.ToolCalls {
+			if call.ID == id {
+				return call.Name
+			}
+		}
+	}
+	return ""
+}
+
+// Part is a media attachment, either inline Data or a URL the provider
+// fetches itself.
+type Part struct {
+	MIMEType string
+	Data     []byte
+	URL      string
+}
+
+// IsImage reports whether p is an image.
+func (p Part) IsImage() bool {
+	return strings.HasPrefix(p.MIMEType, "image/")
+}
+
+// ParseRole validates a role name as sent by clients.
+func ParseRole(role string) (Role, error) {
+	switch r := Role(role); r {
+	case RoleSystem, RoleUser, RoleAssistant, RoleTool:
+		return r, nil
+	case "":
+		return RoleUser, nil
+	default:
+		return "", fmt.Errorf("unknown role %q, expected system, user, assistant or tool", role)
+	}
+}
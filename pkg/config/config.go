@@ -0,0 +1,246 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	anthropicwrapper "github.com/imrany/wrapper/pkg/anthropic"
+	"github.com/imrany/wrapper/pkg/auth"
+	geminiwrapper "github.com/imrany/wrapper/pkg/gemini"
+	ollamawrapper "github.com/imrany/wrapper/pkg/ollama"
+	openaiwrapper "github.com/imrany/wrapper/pkg/openai"
+	"github.com/imrany/wrapper/pkg/provider"
+	"github.com/imrany/wrapper/pkg/ratelimit"
+	"github.com/spf13/viper"
+)
+
+// Config holds the deployment settings read from the optional config file
+// (--config / CONFIG). Flags and environment variables still provide the
+// port, API key and default model.
+type Config struct {
+	// SystemPrompt is the default system prompt of every model.
+	SystemPrompt string `mapstructure:"system_prompt"`
+
+	// Providers holds the settings of each built-in provider.
+	Providers Providers `mapstructure:"providers"`
+
+	// Models lists the models clients may request in addition to the
+	// default model.
+	Models []ModelConfig `mapstructure:"models"`
+
+	// Routing extends the built-in model routing table.
+	Routing Routing `mapstructure:"routing"`
+
+	// Retry controls the retries of transient provider errors.
+	Retry provider.RetryPolicy `mapstructure:"retry"`
+
+	// ResponseFormat controls the validation of JSON responses.
+	ResponseFormat ResponseFormatConfig `mapstructure:"response_format"`
+
+	// Auth holds the client authentication settings, disabled when empty.
+	Auth auth.Config `mapstructure:"auth"`
+
+	// RateLimits limit the requests and tokens of each client.
+	RateLimits ratelimit.Config `mapstructure:"rate_limits"`
+
+	// CORSOrigins are the browser origins allowed to call the REST gateway.
+	// Empty allows any origin.
+	CORSOrigins []string `mapstructure:"cors_origins"`
+}
+
+// ResponseFormatConfig controls the validation of JSON responses.
+type ResponseFormatConfig struct {
+	// RepairRetries is how often a response that fails validation is sent
+	// back to the model for correction. Zero returns the error right away.
+	RepairRetries int `mapstructure:"repair_retries"`
+}
+
+// Routing holds extra routing rules, applied after the built-in providers
+// are registered.
+type Routing struct {
+	// Prefixes route model names starting with a prefix to a provider.
+	Prefixes []PrefixRoute `mapstructure:"prefixes"`
+
+	// Aliases are alternative model names clients may request, e.g "fast".
+	Aliases []Alias `mapstructure:"aliases"`
+
+	// Fallbacks are the models tried when a model keeps failing with
+	// transient errors.
+	Fallbacks []Fallback `mapstructure:"fallbacks"`
+}
+
+// PrefixRoute sends models starting with Prefix to Provider.
+type PrefixRoute struct {
+	Prefix   string `mapstructure:"prefix"`
+	Provider string `mapstructure:"provider"`
+}
+
+// Alias makes Name an alternative name of Model.
+type Alias struct {
+	Name  string `mapstructure:"name"`
+	Model string `mapstructure:"model"`
+}
+
+// Fallback lists the models tried in order when Model fails. The chains of
+// the fallback models themselves are not followed.
+type Fallback struct {
+	Model     string   `mapstructure:"model"`
+	Fallbacks []string `mapstructure:"fallbacks"`
+}
+
+// Providers holds the provider settings. An empty api_key falls back to the
+// API key given by flag or environment.
+type Providers struct {
+	Gemini    geminiwrapper.GeminiClientConfig       `mapstructure:"gemini"`
+	OpenAI    openaiwrapper.OpenAIClientConfig       `mapstructure:"openai"`
+	Anthropic anthropicwrapper.AnthropicClientConfig `mapstructure:"anthropic"`
+	Ollama    ollamawrapper.OllamaClientConfig       `mapstructure:"ollama"`
+}
+
+// ModelConfig holds the settings of a single model.
+type ModelConfig struct {
+	Name string `mapstructure:"name"`
+
+	// SystemPrompt overrides the deployment default system prompt.
+	SystemPrompt string `mapstructure:"system_prompt"`
+
+	// ContextWindow is the maximum number of input tokens, used when the
+	// provider does not report it. Zero means unknown.
+	ContextWindow int `mapstructure:"context_window"`
+
+	// Defaults apply to generation parameters the client leaves unset.
+	Defaults provider.GenerationConfig `mapstructure:"defaults"`
+
+	// Limits clamp the generation parameters sent to the provider.
+	Limits Limits `mapstructure:"limits"`
+}
+
+// Limits are upper bounds for generation parameters, zero means no limit.
+type Limits struct {
+	Temperature     float32 `mapstructure:"temperature"`
+	MaxOutputTokens int32   `mapstructure:"max_output_tokens"`
+	StopSequences   int     `mapstructure:"stop_sequences"`
+}
+
+// Load reads the config file set on v, if any, and decodes it.
+func Load(v *viper.Viper) (*Config, error) {
+	cfg := &Config{}
+	if v.ConfigFileUsed() == "" {
+		return cfg, nil
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode config file: %w", err)
+	}
+
+	for i, m := range cfg.Models {
+		if m.Name == "" {
+			return nil, fmt.Errorf("models[%d]: name cannot be empty", i)
+		}
+		if m.Defaults.Seed != nil && !provider.ValidSeed(*m.Defaults.Seed) {
+			return nil, fmt.Errorf("models[%d]: defaults.seed must fit in 32 bits", i)
+		}
+	}
+	for i, r := range cfg.Routing.Prefixes {
+		if r.Prefix == "" || r.Provider == "" {
+			return nil, fmt.Errorf("routing.prefixes[%d]: prefix and provider are required", i)
+		}
+	}
+	for i, a := range cfg.Routing.Aliases {
+		if a.Name == "" || a.Model == "" {
+			return nil, fmt.Errorf("routing.aliases[%d]: name and model are required", i)
+		}
+	}
+	for i, f := range cfg.Routing.Fallbacks {
+		if f.Model == "" || len(f.Fallbacks) == 0 {
+			return nil, fmt.Errorf("routing.fallbacks[%d]: model and fallbacks are required", i)
+		}
+		seen := make(map[string]bool, len(f.Fallbacks))
+		for _, m := range f.Fallbacks {
+			if strings.EqualFold(m, f.Model) {
+				return nil, fmt.Errorf("routing.fallbacks[%d]: %q cannot fall back to itself", i, m)
+			}
+			if seen[strings.ToLower(m)] {
+				return nil, fmt.Errorf("routing.fallbacks[%d]: %q is listed twice", i, m)
+			}
+			seen[strings.ToLower(m)] = true
+		}
+	}
+	if cfg.ResponseFormat.RepairRetries < 0 {
+		return nil, fmt.Errorf("response_format.repair_retries cannot be negative")
+	}
+	for i, c := range cfg.RateLimits.Clients {
+		if c.Client == "" {
+			return nil, fmt.Errorf("rate_limits.clients[%d]: client is required", i)
+		}
+	}
+	if openai := cfg.Providers.OpenAI; openai.UseAzure() {
+		if openai.BaseURL == "" {
+			return nil, fmt.Errorf("providers.openai: base_url is required for azure")
+		}
+		for i, d := range openai.Deployments {
+			if d.Model == "" || d.Deployment == "" {
+				return nil, fmt.Errorf("providers.openai.deployments[%d]: model and deployment are required", i)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// Model returns the settings of the named model (case-insensitive).
+func (c *Config) Model(name string) (ModelConfig, bool) {
+	if c == nil {
+		return ModelConfig{}, false
+	}
+	for _, m := range c.Models {
+		if strings.EqualFold(m.Name, name) {
+			return m, true
+		}
+	}
+	return ModelConfig{}, false
+}
+
+// AllowsModel reports whether clients may request the named model.
+func (c *Config) AllowsModel(name string) bool {
+	_, ok := c.Model(name)
+	return ok
+}
+
+// ModelNames returns the names of all configured models.
+func (c *Config) ModelNames() []string {
+	if c == nil {
+		return nil
+	}
+	names := make([]string, 0, len(c.Models))
+	for _, m := range c.Models {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+// FallbacksFor returns the fallback models of the named model, in order.
+func (c *Config) FallbacksFor(name string) []string {
+	if c == nil {
+		return nil
+	}
+	for _, f := range c.Routing.Fallbacks {
+		if strings.EqualFold(f.Model, name) {
+			return f.Fallbacks
+		}
+	}
+	return nil
+}
+
+// SystemPromptFor returns the default system prompt of the named model.
+func (c *Config) SystemPromptFor(name string) string {
+	if m, ok := c.Model(name); ok && m.SystemPrompt != "" {
+		return m.SystemPrompt
+	}
+	if c == nil {
+		return ""
+	}
+	return c.SystemPrompt
+}
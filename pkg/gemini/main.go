@@ -2,31 +2,408 @@ package geminiwrapper
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
 
+	"cloud.google.com/go/auth/credentials"
+	"cloud.google.com/go/auth/httptransport"
+	"github.com/imrany/wrapper/pkg/chat"
+	"github.com/imrany/wrapper/pkg/provider"
 	"google.golang.org/genai"
 )
 
+// BackendVertexAI selects Vertex AI instead of the Gemini API.
+const BackendVertexAI = "vertex_ai"
+
+// GeminiClientConfig configures a GeminiClient.
 type GeminiClientConfig struct {
-	APIKey string
-	Model  string
+	APIKey string `mapstructure:"api_key"`
+	// Backend is empty for the Gemini API or BackendVertexAI.
+	Backend string `mapstructure:"backend"`
+	// Project and Location of the Vertex AI endpoint, Location defaults to
+	// "global".
+	Project  string `mapstructure:"project"`
+	Location string `mapstructure:"location"`
+	// CredentialsFile is a service account key file for Vertex AI, defaults
+	// to Application Default Credentials.
+	CredentialsFile string `mapstructure:"credentials_file"`
+	// HTTPClient is shared by all requests, defaults to provider.NewHTTPClient.
+	HTTPClient *http.Client `mapstructure:"-"`
 }
 
-func (g *GeminiClientConfig) GenerateGeminiContent(ctx context.Context, prompt string) (string, error) {
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  g.APIKey,
-		Backend: genai.BackendGeminiAPI,
-	})
+// GeminiClient is the Gemini implementation of provider.Provider. It holds a
+// single genai client for its whole lifetime and is safe for concurrent use.
+type GeminiClient struct {
+	client *genai.Client
+}
+
+var (
+	_ provider.Provider     = (*GeminiClient)(nil)
+	_ provider.TokenCounter = (*GeminiClient)(nil)
+	_ provider.Embedder     = (*GeminiClient)(nil)
+)
+
+// maxEmbedBatch is the most texts the Gemini API embeds in one request.
+const maxEmbedBatch = 100
 
+// NewGeminiClient creates the long-lived Gemini client, call it once at
+// startup.
+func NewGeminiClient(ctx context.Context, config GeminiClientConfig) (*GeminiClient, error) {
+	if config.HTTPClient == nil {
+		config.HTTPClient = provider.NewHTTPClient()
+	}
+
+	clientConfig := &genai.ClientConfig{
+		APIKey:     config.APIKey,
+		Backend:    genai.BackendGeminiAPI,
+		HTTPClient: config.HTTPClient,
+	}
+	if config.UseVertexAI() {
+		var err error
+		if clientConfig, err = vertexAIConfig(config); err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := genai.NewClient(ctx, clientConfig)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return &GeminiClient{client: client}, nil
+}
 
-	result, err := client.Models.GenerateContent(
-		ctx, g.Model, genai.Text(prompt), nil,
+// UseVertexAI reports whether config selects the Vertex AI backend.
+func (c GeminiClientConfig) UseVertexAI() bool {
+	return strings.EqualFold(c.Backend, BackendVertexAI)
+}
+
+// vertexAIConfig authenticates with service account or default credentials.
+// genai skips its own credential setup when given an HTTP client, so the
+// authorization is added to a copy of the shared client here.
+func vertexAIConfig(config GeminiClientConfig) (*genai.ClientConfig, error) {
+	if config.Project == "" {
+		return nil, fmt.Errorf("vertex ai requires a project")
+	}
+
+	creds, err := credentials.DetectDefault(&credentials.DetectOptions{
+		Scopes:          []string{"https://www.googleapis.com/auth/cloud-platform"},
+		CredentialsFile: config.CredentialsFile,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vertex ai credentials: %w", err)
+	}
+
+	httpClient := *config.HTTPClient
+	if err := httptransport.AddAuthorizationMiddleware(&httpClient, creds); err != nil {
+		return nil, err
+	}
+
+	location := config.Location
+	if location == "" {
+		location = "global"
+	}
+	return &genai.ClientConfig{
+		Backend:     genai.BackendVertexAI,
+		Project:     config.Project,
+		Location:    location,
+		Credentials: creds,
+		HTTPClient:  &httpClient,
+	}, nil
+}
+
+func (g *GeminiClient) Name() string {
+	return "gemini"
+}
+
+func (g *GeminiClient) Generate(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	contents, config := toGeminiRequest(req)
+	result, err := g.client.Models.GenerateContent(
+		ctx, req.Model, contents, config,
 	)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	resp := &provider.Response{Text: result.Text()}
+	collectMetadata(resp, result)
+	collectToolCalls(resp, result)
+	return resp, nil
+}
+
+func (g *GeminiClient) Stream(ctx context.Context, req *provider.Request, onChunk func(string) error) (*provider.Response, error) {
+	var text strings.Builder
+	resp := &provider.Response{}
+	contents, config := toGeminiRequest(req)
+	for result, err := range g.client.Models.GenerateContentStream(
+		ctx, req.Model, contents, config,
+	) {
+		if err != nil {
+			return nil, err
+		}
+
+		collectMetadata(resp, result)
+		collectToolCalls(resp, result)
+		if chunk := result.Text(); chunk != "" {
+			text.WriteString(chunk)
+			if err := onChunk(chunk); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	resp.Text = text.String()
+	return resp, nil
+}
+
+func (g *GeminiClient) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	var models []provider.ModelInfo
+	for m, err := range g.client.Models.All(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		// Gemini API names are "models/<id>", Vertex AI names are
+		// "publishers/google/models/<id>".
+		models = append(models, provider.ModelInfo{
+			ID:            m.Name[strings.LastIndex(m.Name, "/")+1:],
+			Provider:      g.Name(),
+			DisplayName:   m.DisplayName,
+			ContextWindow: int(m.InputTokenLimit),
+		})
+	}
+	return models, nil
+}
+
+// CountTokens counts the input tokens of req. The Gemini API does not accept
+// a system instruction when counting, so it is counted as a leading turn.
+func (g *GeminiClient) CountTokens(ctx context.Context, req *provider.Request) (int32, error) {
+	contents, config := toGeminiRequest(req)
+
+	// Vertex AI counts the system instruction and tools itself. The Gemini
+	// API rejects both, so the system instruction is counted as a turn and
+	// the tools are estimated.
+	if g.client.ClientConfig().Backend == genai.BackendVertexAI {
+		result, err := g.client.Models.CountTokens(ctx, req.Model, contents, &genai.CountTokensConfig{
+			SystemInstruction: config.SystemInstruction,
+			Tools:             config.Tools,
+		})
+		if err != nil {
+			return 0, err
+		}
+		return result.TotalTokens, nil
+	}
+
+	if config.SystemInstruction != nil {
+		system := genai.NewContentFromParts(config.SystemInstruction.Parts, genai.RoleUser)
+		contents = append([]*genai.Content{system}, contents...)
+	}
+	result, err := g.client.Models.CountTokens(ctx, req.Model, contents, nil)
+	if err != nil {
+		return 0, err
+	}
+	return result.TotalTokens + provider.EstimateToolTokens(req), nil
+}
+
+// Embed embeds the inputs in batches of maxEmbedBatch. Gemini does not
+// report token usage for embeddings.
+func (g *GeminiClient) Embed(ctx context.Context, req *provider.EmbedRequest) (*provider.EmbedResponse, error) {
+	config := &genai.EmbedContentConfig{
+		TaskType:             req.TaskType,
+		OutputDimensionality: req.Dimensions,
+	}
+
+	resp := &provider.EmbedResponse{Embeddings: make([][]float32, 0, len(req.Inputs))}
+	for start := 0; start < len(req.Inputs); start += maxEmbedBatch {
+		end := min(start+maxEmbedBatch, len(req.Inputs))
+		contents := make([]*genai.Content, 0, end-start)
+		for _, input := range req.Inputs[start:end] {
+			contents = append(contents, genai.NewContentFromText(input, genai.RoleUser))
+		}
+
+		result, err := g.client.Models.EmbedContent(ctx, req.Model, contents, config)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Embeddings) != len(contents) {
+			return nil, fmt.Errorf("got %d embeddings for %d inputs", len(result.Embeddings), len(contents))
+		}
+		for _, e := range result.Embeddings {
+			resp.Embeddings = append(resp.Embeddings, e.Values)
+		}
+	}
+	return resp, nil
+}
+
+// Capabilities reports the features of Gemini models; every current
+// Gemini model is multimodal and supports tools and JSON output.
+func (g *GeminiClient) Capabilities(_ string) provider.Capabilities {
+	return provider.Capabilities{
+		Streaming: true,
+		Vision:    true,
+		Tools:     true,
+		JSONMode:  true,
+	}
+}
+
+// Retryable reports whether err is a rate limit, server error or network
+// failure.
+func (g *GeminiClient) Retryable(err error) bool {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return provider.RetryableStatus(apiErr.Code)
 	}
+	return provider.IsTransient(err)
+}
 
-	return result.Text(), nil
+// collectMetadata copies usage, finish reason and model version from result
+// into resp. When streaming, only the final chunk carries the finish reason
+// and complete usage, so fields already set are only overwritten by newer
+// values.
+func collectMetadata(resp *provider.Response, result *genai.GenerateContentResponse) {
+	if result.ModelVersion != "" {
+		resp.ModelVersion = result.ModelVersion
+	}
+	if u := result.UsageMetadata; u != nil {
+		resp.Usage = provider.Usage{
+			PromptTokens:     u.PromptTokenCount,
+			CompletionTokens: u.CandidatesTokenCount + u.ThoughtsTokenCount,
+			TotalTokens:      u.TotalTokenCount,
+		}
+	}
+	if len(result.Candidates) > 0 && result.Candidates[0].FinishReason != "" {
+		resp.FinishReason = finishReason(result.Candidates[0].FinishReason)
+	}
+	// A blocked prompt has no candidates, only the reason it was blocked.
+	if f := result.PromptFeedback; f != nil && f.BlockReason != "" {
+		resp.FinishReason = provider.FinishSafety
+	}
+}
+
+// collectToolCalls appends the function calls of result to resp. Gemini
+// only assigns call IDs on Vertex AI, so missing IDs are generated.
+func collectToolCalls(resp *provider.Response, result *genai.GenerateContentResponse) {
+	for _, call := range result.FunctionCalls() {
+		id := call.ID
+		if id == "" {
+			id = chat.NewToolCallID()
+		}
+		resp.ToolCalls = append(resp.ToolCalls, chat.ToolCall{ID: id, Name: call.Name, Arguments: call.Args})
+	}
+	if len(resp.ToolCalls) > 0 {
+		resp.FinishReason = provider.FinishToolCalls
+	}
+}
+
+func finishReason(reason genai.FinishReason) provider.FinishReason {
+	switch reason {
+	case genai.FinishReasonStop:
+		return provider.FinishStop
+	case genai.FinishReasonMaxTokens:
+		return provider.FinishLength
+	case genai.FinishReasonSafety, genai.FinishReasonRecitation, genai.FinishReasonBlocklist,
+		genai.FinishReasonProhibitedContent, genai.FinishReasonSPII,
+		genai.FinishReasonImageSafety, genai.FinishReasonImageProhibitedContent:
+		return provider.FinishSafety
+	default:
+		return provider.FinishOther
+	}
+}
+
+// toGeminiRequest maps the conversation to genai history and the generation
+// parameters and response format to the content config. Gemini has no system
+// turns, so system messages are appended to the system instruction.
+func toGeminiRequest(req *provider.Request) ([]*genai.Content, *genai.GenerateContentConfig) {
+	config := &genai.GenerateContentConfig{
+		Temperature:   req.Generation.Temperature,
+		TopP:          req.Generation.TopP,
+		StopSequences: req.Generation.StopSequences,
+	}
+	if req.Generation.MaxOutputTokens != nil {
+		config.MaxOutputTokens = *req.Generation.MaxOutputTokens
+	}
+	if req.Generation.Seed != nil {
+		config.Seed = genai.Ptr(int32(*req.Generation.Seed))
+	}
+
+	var contents []*genai.Content
+	var system []*genai.Part
+	if req.SystemInstruction != "" {
+		system = append(system, genai.NewPartFromText(req.SystemInstruction))
+	}
+	for i, m := range req.Messages {
+		switch m.Role {
+		case chat.RoleSystem:
+			system = append(system, genai.NewPartFromText(m.Content))
+		case chat.RoleAssistant:
+			contents = append(contents, toContent(m, genai.RoleModel))
+		case chat.RoleTool:
+			// The responses to parallel calls must share a single turn.
+			response := genai.NewPartFromFunctionResponse(chat.ToolName(req.Messages, m.ToolCallID), toolResult(m.Content))
+			response.FunctionResponse.ID = m.ToolCallID
+			if last := len(contents) - 1; i > 0 && req.Messages[i-1].Role == chat.RoleTool {
+				contents[last].Parts = append(contents[last].Parts, response)
+			} else {
+				contents = append(contents, genai.NewContentFromParts([]*genai.Part{response}, genai.RoleUser))
+			}
+		default:
+			contents = append(contents, toContent(m, genai.RoleUser))
+		}
+	}
+	if len(system) > 0 {
+		config.SystemInstruction = &genai.Content{Parts: system}
+	}
+
+	if len(req.Tools) > 0 {
+		declarations := make([]*genai.FunctionDeclaration, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			declaration := &genai.FunctionDeclaration{Name: tool.Name, Description: tool.Description}
+			if tool.Parameters != nil {
+				declaration.ParametersJsonSchema = tool.Parameters
+			}
+			declarations = append(declarations, declaration)
+		}
+		config.Tools = []*genai.Tool{{FunctionDeclarations: declarations}}
+	}
+
+	if format := req.ResponseFormat; format != nil {
+		config.ResponseMIMEType = "application/json"
+		if format.Type == provider.ResponseJSONSchema {
+			config.ResponseJsonSchema = format.Schema
+		}
+	}
+
+	return contents, config
+}
+
+// toContent maps the text and media parts of m to a single turn.
+func toContent(m chat.Message, role genai.Role) *genai.Content {
+	parts := make([]*genai.Part, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		parts = append(parts, genai.NewPartFromText(m.Content))
+	}
+	for _, part := range m.Parts {
+		if part.URL != "" {
+			parts = append(parts, genai.NewPartFromURI(part.URL, part.MIMEType))
+		} else {
+			parts = append(parts, genai.NewPartFromBytes(part.Data, part.MIMEType))
+		}
+	}
+	for _, call := range m.ToolCalls {
+		part := genai.NewPartFromFunctionCall(call.Name, call.Arguments)
+		part.FunctionCall.ID = call.ID
+		parts = append(parts, part)
+	}
+	return genai.NewContentFromParts(parts, role)
+}
+
+// toolResult wraps a tool result in the object Gemini expects: a JSON object
+// result is sent as is, anything else under "output".
+func toolResult(content string) map[string]any {
+	var object map[string]any
+	if err := json.Unmarshal([]byte(content), &object); err == nil && object != nil {
+		return object
+	}
+	return map[string]any{"output": content}
 }
@@ -0,0 +1,476 @@
+package ollamawrapper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/imrany/wrapper/pkg/chat"
+	"github.com/imrany/wrapper/pkg/provider"
+)
+
+const defaultBaseURL = "http://localhost:11434"
+
+// OllamaClientConfig configures an OllamaClient.
+type OllamaClientConfig struct {
+	// BaseURL defaults to http://localhost:11434.
+	BaseURL string `mapstructure:"base_url"`
+	// KeepAlive controls how long models stay loaded, e.g "10m", or "-1m"
+	// to keep them loaded. A value without unit is a number of seconds.
+	KeepAlive string `mapstructure:"keep_alive"`
+	// Options are passed through to every request, e.g num_ctx or num_gpu.
+	// Generation parameters of the request take precedence.
+	Options map[string]any `mapstructure:"options"`
+	// HTTPClient is shared by all requests, defaults to provider.NewHTTPClient.
+	HTTPClient *http.Client `mapstructure:"-"`
+}
+
+// OllamaClient is the Ollama implementation of provider.Provider, using the
+// native /api/generate and /api/chat endpoints. It is safe for concurrent use.
+type OllamaClient struct {
+	config OllamaClientConfig
+}
+
+var (
+	_ provider.Provider = (*OllamaClient)(nil)
+	_ provider.Embedder = (*OllamaClient)(nil)
+)
+
+// NewOllamaClient creates the long-lived Ollama client, call it once at
+// startup.
+func NewOllamaClient(config OllamaClientConfig) *OllamaClient {
+	if config.HTTPClient == nil {
+		config.HTTPClient = provider.NewHTTPClient()
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = defaultBaseURL
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	return &OllamaClient{config: config}
+}
+
+func (o *OllamaClient) Name() string {
+	return "ollama"
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Images are base64 encoded by encoding/json.
+	Images    [][]byte   `json:"images,omitempty"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+	// ToolName is the tool a tool message answers, Ollama has no call IDs.
+	ToolName string `json:"tool_name,omitempty"`
+}
+
+type toolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+type tool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description,omitempty"`
+		Parameters  map[string]any `json:"parameters,omitempty"`
+	} `json:"function"`
+}
+
+type request struct {
+	Model     string         `json:"model"`
+	Messages  []message      `json:"messages,omitempty"`
+	Prompt    string         `json:"prompt,omitempty"`
+	Images    [][]byte       `json:"images,omitempty"`
+	System    string         `json:"system,omitempty"`
+	Tools     []tool         `json:"tools,omitempty"`
+	Format    any            `json:"format,omitempty"`
+	Stream    bool           `json:"stream"`
+	KeepAlive keepAlive      `json:"keep_alive,omitempty"`
+	Options   map[string]any `json:"options,omitempty"`
+}
+
+// response is a /api/chat or /api/generate response, or one line of their
+// streamed form.
+type response struct {
+	Model           string   `json:"model"`
+	Message         *message `json:"message,omitempty"`
+	Response        string   `json:"response"`
+	Done            bool     `json:"done"`
+	DoneReason      string   `json:"done_reason"`
+	PromptEvalCount int32    `json:"prompt_eval_count"`
+	EvalCount       int32    `json:"eval_count"`
+	Error           string   `json:"error"`
+}
+
+func (r *response) text() string {
+	if r.Message != nil {
+		return r.Message.Content
+	}
+	return r.Response
+}
+
+func (o *OllamaClient) Generate(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	path, body, err := o.toRequest(req, false)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := o.post(ctx, path, body)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp response
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama response: %w", err)
+	}
+	var calls []toolCall
+	if resp.Message != nil {
+		calls = resp.Message.ToolCalls
+	}
+	return toResponse(&resp, resp.text(), calls), nil
+}
+
+func (o *OllamaClient) Stream(ctx context.Context, req *provider.Request, onChunk func(string) error) (*provider.Response, error) {
+	path, body, err := o.toRequest(req, true)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := o.post(ctx, path, body)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	// The stream is newline-delimited JSON, the last object has done set.
+	var text strings.Builder
+	var calls []toolCall
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var resp response
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return nil, fmt.Errorf("failed to decode ollama stream: %w", err)
+		}
+		if resp.Error != "" {
+			return nil, fmt.Errorf("ollama: %s", resp.Error)
+		}
+
+		if resp.Message != nil {
+			calls = append(calls, resp.Message.ToolCalls...)
+		}
+		if chunk := resp.text(); chunk != "" {
+			text.WriteString(chunk)
+			if err := onChunk(chunk); err != nil {
+				return nil, err
+			}
+		}
+		if resp.Done {
+			return toResponse(&resp, text.String(), calls), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.ErrUnexpectedEOF
+}
+
+func (o *OllamaClient) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, o.config.BaseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := o.do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama models: %w", err)
+	}
+
+	models := make([]provider.ModelInfo, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		models = append(models, provider.ModelInfo{
+			ID:       o.Name() + "/" + m.Name,
+			Provider: o.Name(),
+		})
+	}
+	return models, nil
+}
+
+// keepAlive is sent as a number when it has no unit, as Ollama parses
+// strings as durations and rejects e.g "-1".
+type keepAlive string
+
+func (k keepAlive) MarshalJSON() ([]byte, error) {
+	if seconds, err := strconv.ParseFloat(string(k), 64); err == nil {
+		return json.Marshal(seconds)
+	}
+	return json.Marshal(string(k))
+}
+
+type embedRequest struct {
+	Model      string         `json:"model"`
+	Input      []string       `json:"input"`
+	Dimensions int32          `json:"dimensions,omitempty"`
+	KeepAlive  keepAlive      `json:"keep_alive,omitempty"`
+	Options    map[string]any `json:"options,omitempty"`
+}
+
+type embedResponse struct {
+	Embeddings      [][]float32 `json:"embeddings"`
+	PromptEvalCount int32       `json:"prompt_eval_count"`
+}
+
+// Embed embeds all inputs with a single /api/embed request.
+func (o *OllamaClient) Embed(ctx context.Context, req *provider.EmbedRequest) (*provider.EmbedResponse, error) {
+	body := &embedRequest{
+		Model:     req.Model,
+		Input:     req.Inputs,
+		KeepAlive: keepAlive(o.config.KeepAlive),
+		Options:   o.config.Options,
+	}
+	if req.Dimensions != nil {
+		body.Dimensions = *req.Dimensions
+	}
+
+	httpResp, err := o.post(ctx, "/api/embed", body)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp embedResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama embeddings: %w", err)
+	}
+	if len(resp.Embeddings) != len(req.Inputs) {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(resp.Embeddings), len(req.Inputs))
+	}
+	return &provider.EmbedResponse{
+		Embeddings: resp.Embeddings,
+		Usage: provider.Usage{
+			PromptTokens: resp.PromptEvalCount,
+			TotalTokens:  resp.PromptEvalCount,
+		},
+	}, nil
+}
+
+// Capabilities reports the features every Ollama model supports; vision and
+// tools depend on the model family and are not advertised.
+func (o *OllamaClient) Capabilities(_ string) provider.Capabilities {
+	return provider.Capabilities{
+		Streaming: true,
+		JSONMode:  true,
+	}
+}
+
+// Retryable reports whether err is a server error, e.g while a model is
+// loading, or a network failure such as Ollama not running yet.
+func (o *OllamaClient) Retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return provider.RetryableStatus(statusErr.StatusCode)
+	}
+	return provider.IsTransient(err)
+}
+
+// toRequest maps req to an Ollama request. A single user prompt goes to
+// /api/generate, whole conversations go to /api/chat.
+func (o *OllamaClient) toRequest(req *provider.Request, stream bool) (string, *request, error) {
+	out := &request{
+		Model:     req.Model,
+		Stream:    stream,
+		KeepAlive: keepAlive(o.config.KeepAlive),
+		Options:   o.options(req.Generation),
+	}
+
+	for _, t := range req.Tools {
+		declaration := tool{Type: "function"}
+		declaration.Function.Name = t.Name
+		declaration.Function.Description = t.Description
+		declaration.Function.Parameters = t.Parameters
+		out.Tools = append(out.Tools, declaration)
+	}
+
+	// Format is "json" or the JSON Schema of the response.
+	if format := req.ResponseFormat; format != nil {
+		out.Format = "json"
+		if format.Type == provider.ResponseJSONSchema {
+			out.Format = format.Schema
+		}
+	}
+
+	// Tools are only supported by /api/chat.
+	if len(req.Messages) == 1 && req.Messages[0].Role == chat.RoleUser && len(req.Tools) == 0 {
+		images, err := toImages(req.Messages[0].Parts)
+		if err != nil {
+			return "", nil, err
+		}
+		out.Prompt = req.Messages[0].Content
+		out.Images = images
+		out.System = req.SystemInstruction
+		return "/api/generate", out, nil
+	}
+
+	if req.SystemInstruction != "" {
+		out.Messages = append(out.Messages, message{Role: string(chat.RoleSystem), Content: req.SystemInstruction})
+	}
+	for _, m := range req.Messages {
+		images, err := toImages(m.Parts)
+		if err != nil {
+			return "", nil, err
+		}
+		msg := message{Role: string(m.Role), Content: m.Content, Images: images}
+		for _, call := range m.ToolCalls {
+			var tc toolCall
+			tc.Function.Name = call.Name
+			tc.Function.Arguments = call.Arguments
+			msg.ToolCalls = append(msg.ToolCalls, tc)
+		}
+		if m.Role == chat.RoleTool {
+			msg.ToolName = chat.ToolName(req.Messages, m.ToolCallID)
+		}
+		out.Messages = append(out.Messages, msg)
+	}
+	return "/api/chat", out, nil
+}
+
+// toImages returns the inline images of parts. Ollama does not fetch URLs
+// and only accepts images.
+func toImages(parts []chat.Part) ([][]byte, error) {
+	var images [][]byte
+	for _, part := range parts {
+		if !part.IsImage() || part.URL != "" {
+			return nil, fmt.Errorf("%w: ollama accepts inline images only", provider.ErrUnsupportedContent)
+		}
+		images = append(images, part.Data)
+	}
+	return images, nil
+}
+
+// options merges the configured options with the generation parameters.
+func (o *OllamaClient) options(gen provider.GenerationConfig) map[string]any {
+	options := make(map[string]any, len(o.config.Options)+5)
+	for key, value := range o.config.Options {
+		options[key] = value
+	}
+
+	if gen.Temperature != nil {
+		options["temperature"] = *gen.Temperature
+	}
+	if gen.TopP != nil {
+		options["top_p"] = *gen.TopP
+	}
+	if gen.MaxOutputTokens != nil {
+		options["num_predict"] = *gen.MaxOutputTokens
+	}
+	if len(gen.StopSequences) > 0 {
+		options["stop"] = gen.StopSequences
+	}
+	if gen.Seed != nil {
+		options["seed"] = *gen.Seed
+	}
+	return options
+}
+
+func (o *OllamaClient) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.config.BaseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	return o.do(httpReq)
+}
+
+// do sends httpReq and turns non-2xx responses into errors.
+func (o *OllamaClient) do(httpReq *http.Request) (*http.Response, error) {
+	httpResp, err := o.config.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode >= 200 && httpResp.StatusCode < 300 {
+		return httpResp, nil
+	}
+	defer httpResp.Body.Close()
+
+	var body response
+	data, _ := io.ReadAll(io.LimitReader(httpResp.Body, 64*1024))
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		return nil, &StatusError{StatusCode: httpResp.StatusCode, Message: body.Error}
+	}
+	return nil, &StatusError{StatusCode: httpResp.StatusCode, Message: strings.TrimSpace(string(data))}
+}
+
+// StatusError is returned when Ollama answers with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ollama: status %d: %s", e.StatusCode, e.Message)
+}
+
+// toResponse builds the result from the final response object. Ollama
+// assigns no tool call IDs, so they are generated.
+func toResponse(resp *response, text string, calls []toolCall) *provider.Response {
+	reason := provider.FinishOther
+	switch resp.DoneReason {
+	case "stop", "":
+		reason = provider.FinishStop
+	case "length":
+		reason = provider.FinishLength
+	}
+
+	var toolCalls []chat.ToolCall
+	for _, call := range calls {
+		toolCalls = append(toolCalls, chat.ToolCall{
+			ID:        chat.NewToolCallID(),
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	if len(toolCalls) > 0 {
+		reason = provider.FinishToolCalls
+	}
+
+	return &provider.Response{
+		Text:         text,
+		ToolCalls:    toolCalls,
+		FinishReason: reason,
+		Usage: provider.Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+			TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+		},
+		ModelVersion: resp.Model,
+	}
+}
@@ -2,39 +2,534 @@ package openaiwrapper
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
 
+	"github.com/imrany/wrapper/pkg/chat"
+	"github.com/imrany/wrapper/pkg/provider"
 	openai "github.com/sashabaranov/go-openai"
 )
 
+// API types of OpenAIClientConfig.APIType.
+const (
+	// APITypeAzure authenticates to Azure OpenAI with an api-key header.
+	APITypeAzure = "azure"
+	// APITypeAzureAD authenticates to Azure OpenAI with a Microsoft Entra ID
+	// bearer token in APIKey.
+	APITypeAzureAD = "azure_ad"
+)
+
+// OpenAIClientConfig configures an OpenAIClient. Setting BaseURL targets any
+// OpenAI-compatible server, e.g vLLM, Ollama, LocalAI or an internal gateway.
+type OpenAIClientConfig struct {
+	APIKey string `mapstructure:"api_key"`
+	// BaseURL defaults to https://api.openai.com/v1. For Azure it is the
+	// resource endpoint, e.g https://my-resource.openai.azure.com.
+	BaseURL      string `mapstructure:"base_url"`
+	Organization string `mapstructure:"organization"`
+	// Headers are added to every request sent to the server.
+	Headers map[string]string `mapstructure:"headers"`
+	// APIType is empty for OpenAI-compatible servers, APITypeAzure or
+	// APITypeAzureAD.
+	APIType string `mapstructure:"api_type"`
+	// APIVersion is the Azure api-version, defaults to go-openai's.
+	APIVersion string `mapstructure:"api_version"`
+	// Deployments maps public model names to Azure deployment names. Models
+	// without one use their name without dots and colons, e.g gpt-35-turbo.
+	Deployments []AzureDeployment `mapstructure:"deployments"`
+	// HTTPClient is shared by all requests, defaults to provider.NewHTTPClient.
+	HTTPClient *http.Client `mapstructure:"-"`
+}
+
+// AzureDeployment is the Azure deployment serving Model. It is a list entry
+// rather than a map because viper splits map keys on dots, e.g gpt-4.1.
+type AzureDeployment struct {
+	Model      string `mapstructure:"model"`
+	Deployment string `mapstructure:"deployment"`
+}
+
+// UseAzure reports whether config targets Azure OpenAI.
+func (c OpenAIClientConfig) UseAzure() bool {
+	return strings.EqualFold(c.APIType, APITypeAzure) || strings.EqualFold(c.APIType, APITypeAzureAD)
+}
+
+// OpenAIClient is the OpenAI implementation of provider.Provider. It holds a
+// single go-openai client for its whole lifetime and is safe for concurrent
+// use.
 type OpenAIClient struct {
-	APIKey string
-	Model  string
-}
-
-func (o *OpenAIClient) GenerateOpenAIContent(ctx context.Context, prompt string) (string, error) {
-	client := openai.NewClient(o.APIKey)
-
-	resp, err := client.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: o.Model,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
-			},
+	client *openai.Client
+}
+
+var (
+	_ provider.Provider = (*OpenAIClient)(nil)
+	_ provider.Embedder = (*OpenAIClient)(nil)
+)
+
+// NewOpenAIClient creates the long-lived OpenAI client, call it once at
+// startup.
+func NewOpenAIClient(config OpenAIClientConfig) *OpenAIClient {
+	if config.HTTPClient == nil {
+		config.HTTPClient = provider.NewHTTPClient()
+	}
+
+	clientConfig := openai.DefaultConfig(config.APIKey)
+	if config.UseAzure() {
+		clientConfig = azureConfig(config)
+	}
+	clientConfig.HTTPClient = config.HTTPClient
+	if config.BaseURL != "" {
+		clientConfig.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	}
+	clientConfig.OrgID = config.Organization
+	if len(config.Headers) > 0 {
+		clientConfig.HTTPClient = withHeaders(config.HTTPClient, config.Headers)
+	}
+	return &OpenAIClient{client: openai.NewClientWithConfig(clientConfig)}
+}
+
+// azureConfig maps model names to deployments, falling back to go-openai's
+// default mapping.
+func azureConfig(config OpenAIClientConfig) openai.ClientConfig {
+	clientConfig := openai.DefaultAzureConfig(config.APIKey, config.BaseURL)
+	if strings.EqualFold(config.APIType, APITypeAzureAD) {
+		clientConfig.APIType = openai.APITypeAzureAD
+	}
+	if config.APIVersion != "" {
+		clientConfig.APIVersion = config.APIVersion
+	}
+
+	deployments := make(map[string]string, len(config.Deployments))
+	for _, d := range config.Deployments {
+		deployments[strings.ToLower(d.Model)] = d.Deployment
+	}
+	defaultMapper := clientConfig.AzureModelMapperFunc
+	clientConfig.AzureModelMapperFunc = func(model string) string {
+		if deployment, ok := deployments[strings.ToLower(model)]; ok {
+			return deployment
+		}
+		return defaultMapper(model)
+	}
+	return clientConfig
+}
+
+// headerTransport adds fixed headers to every request.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, values := range t.headers {
+		req.Header[key] = values
+	}
+	return t.base.RoundTrip(req)
+}
+
+// withHeaders returns a copy of client that sends headers with every request,
+// sharing the underlying transport.
+func withHeaders(client *http.Client, headers map[string]string) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	h := make(http.Header, len(headers))
+	for key, value := range headers {
+		h.Set(key, value)
+	}
+
+	withHeaders := *client
+	withHeaders.Transport = &headerTransport{base: base, headers: h}
+	return &withHeaders
+}
+
+func (o *OpenAIClient) Name() string {
+	return "openai"
+}
+
+func (o *OpenAIClient) Generate(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	request, err := toOpenAIRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+	message := resp.Choices[0].Message
+	if message.Content == "" && len(message.ToolCalls) == 0 {
+		return nil, fmt.Errorf("empty response")
+	}
+
+	toolCalls, err := toToolCalls(message.ToolCalls)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.Response{
+		Text:         message.Content,
+		ToolCalls:    toolCalls,
+		FinishReason: finishReason(resp.Choices[0].FinishReason),
+		Usage:        toUsage(resp.Usage),
+		ModelVersion: resp.Model,
+	}, nil
+}
+
+func (o *OpenAIClient) Stream(ctx context.Context, req *provider.Request, onChunk func(string) error) (*provider.Response, error) {
+	request, err := toOpenAIRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	request.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := o.client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	var text strings.Builder
+	var calls []openai.ToolCall
+	result := &provider.Response{}
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			result.Text = text.String()
+			if result.ToolCalls, err = toToolCalls(calls); err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.Model != "" {
+			result.ModelVersion = resp.Model
+		}
+		if resp.Usage != nil {
+			result.Usage = toUsage(*resp.Usage)
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+		if resp.Choices[0].FinishReason != "" {
+			result.FinishReason = finishReason(resp.Choices[0].FinishReason)
+		}
+		calls = accumulateToolCalls(calls, resp.Choices[0].Delta.ToolCalls)
+		if delta := resp.Choices[0].Delta.Content; delta != "" {
+			text.WriteString(delta)
+			if err := onChunk(delta); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (o *OpenAIClient) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	list, err := o.client.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]provider.ModelInfo, 0, len(list.Models))
+	for _, m := range list.Models {
+		models = append(models, provider.ModelInfo{
+			ID:       m.ID,
+			Provider: o.Name(),
+		})
+	}
+	return models, nil
+}
+
+func (o *OpenAIClient) Embed(ctx context.Context, req *provider.EmbedRequest) (*provider.EmbedResponse, error) {
+	request := openai.EmbeddingRequestStrings{
+		Input: req.Inputs,
+		Model: openai.EmbeddingModel(req.Model),
+	}
+	if req.Dimensions != nil {
+		request.Dimensions = int(*req.Dimensions)
+	}
+
+	result, err := o.client.CreateEmbeddings(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Data) != len(req.Inputs) {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(result.Data), len(req.Inputs))
+	}
+
+	// Data is not guaranteed to be in input order.
+	embeddings := make([][]float32, len(result.Data))
+	for _, e := range result.Data {
+		if e.Index < 0 || e.Index >= len(embeddings) {
+			return nil, fmt.Errorf("embedding index %d out of range", e.Index)
+		}
+		embeddings[e.Index] = e.Embedding
+	}
+	return &provider.EmbedResponse{
+		Embeddings: embeddings,
+		Usage: provider.Usage{
+			PromptTokens: int32(result.Usage.PromptTokens),
+			TotalTokens:  int32(result.Usage.TotalTokens),
 		},
-	)
+	}, nil
+}
+
+// Capabilities reports the features of OpenAI chat models. The original
+// o1 preview/mini releases lack vision, tools and JSON mode, and gpt-3.5
+// is text only.
+func (o *OpenAIClient) Capabilities(model string) provider.Capabilities {
+	model = strings.ToLower(model)
+	if strings.HasPrefix(model, "o1-preview") || strings.HasPrefix(model, "o1-mini") {
+		return provider.Capabilities{Streaming: true}
+	}
+	return provider.Capabilities{
+		Streaming: true,
+		Vision:    !strings.HasPrefix(model, "gpt-3.5"),
+		Tools:     true,
+		JSONMode:  true,
+	}
+}
+
+// Retryable reports whether err is a rate limit, server error or network
+// failure.
+func (o *OpenAIClient) Retryable(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return provider.RetryableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return provider.RetryableStatus(reqErr.HTTPStatusCode)
+	}
+	return provider.IsTransient(err)
+}
+
+func toUsage(u openai.Usage) provider.Usage {
+	return provider.Usage{
+		PromptTokens:     int32(u.PromptTokens),
+		CompletionTokens: int32(u.CompletionTokens),
+		TotalTokens:      int32(u.TotalTokens),
+	}
+}
+
+func finishReason(reason openai.FinishReason) provider.FinishReason {
+	switch reason {
+	case openai.FinishReasonStop:
+		return provider.FinishStop
+	case openai.FinishReasonLength:
+		return provider.FinishLength
+	case openai.FinishReasonContentFilter:
+		return provider.FinishSafety
+	case openai.FinishReasonToolCalls, openai.FinishReasonFunctionCall:
+		return provider.FinishToolCalls
+	default:
+		return provider.FinishOther
+	}
+}
+
+// accumulateToolCalls merges streamed tool call deltas into calls. The first
+// delta of a call carries its ID and name, later ones append to the
+// arguments.
+func accumulateToolCalls(calls []openai.ToolCall, deltas []openai.ToolCall) []openai.ToolCall {
+	for _, delta := range deltas {
+		index := len(calls)
+		if delta.Index != nil {
+			index = *delta.Index
+		}
+		for len(calls) <= index {
+			calls = append(calls, openai.ToolCall{})
+		}
+
+		call := &calls[index]
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Function.Name != "" {
+			call.Function.Name = delta.Function.Name
+		}
+		call.Function.Arguments += delta.Function.Arguments
+	}
+	return calls
+}
+
+// toToolCalls decodes the JSON arguments of calls.
+func toToolCalls(calls []openai.ToolCall) ([]chat.ToolCall, error) {
+	var out []chat.ToolCall
+	for _, call := range calls {
+		var args map[string]any
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("tool call %s: invalid arguments: %w", call.Function.Name, err)
+			}
+		}
+		out = append(out, chat.ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: args})
+	}
+	return out, nil
+}
 
+// toOpenAIRequest maps req to a chat completion request. Reasoning models
+// (o-series) only accept max_completion_tokens.
+func toOpenAIRequest(req *provider.Request) (openai.ChatCompletionRequest, error) {
+	messages, err := toOpenAIMessages(req.SystemInstruction, req.Messages)
 	if err != nil {
-		return "", err
+		return openai.ChatCompletionRequest{}, err
+	}
+
+	out := openai.ChatCompletionRequest{
+		Model:    req.Model,
+		Messages: messages,
+		Stop:     req.Generation.StopSequences,
+	}
+	for _, tool := range req.Tools {
+		out.Tools = append(out.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
 	}
 
-	if resp.Choices[0].Message.Content == "" {
-		return "", fmt.Errorf("empty response")
+	if format := req.ResponseFormat; format != nil {
+		out.ResponseFormat = toResponseFormat(format)
 	}
 
-	return resp.Choices[0].Message.Content, nil
+	gen := req.Generation
+	if gen.Temperature != nil {
+		out.Temperature = *gen.Temperature
+		if out.Temperature == 0 {
+			// go-openai omits a zero temperature, send the smallest
+			// non-zero value to get deterministic output.
+			out.Temperature = math.SmallestNonzeroFloat32
+		}
+	}
+	if gen.TopP != nil {
+		out.TopP = *gen.TopP
+	}
+	if gen.MaxOutputTokens != nil {
+		if isReasoningModel(req.Model) {
+			out.MaxCompletionTokens = int(*gen.MaxOutputTokens)
+		} else {
+			out.MaxTokens = int(*gen.MaxOutputTokens)
+		}
+	}
+	if gen.Seed != nil {
+		seed := int(*gen.Seed)
+		out.Seed = &seed
+	}
+	return out, nil
+}
+
+// toResponseFormat maps format to OpenAI's response_format, which requires a
+// schema name.
+func toResponseFormat(format *provider.ResponseFormat) *openai.ChatCompletionResponseFormat {
+	if format.Type != provider.ResponseJSONSchema {
+		return &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	}
+	name := format.Name
+	if name == "" {
+		name = "response"
+	}
+	return &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   name,
+			Schema: jsonSchema(format.Schema),
+		},
+	}
+}
+
+// jsonSchema lets a decoded schema be sent as a json.Marshaler.
+type jsonSchema map[string]any
+
+func (s jsonSchema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any(s))
+}
+
+func isReasoningModel(model string) bool {
+	model = strings.ToLower(model)
+	return len(model) > 1 && model[0] == 'o' && model[1] >= '0' && model[1] <= '9'
+}
+
+func toOpenAIMessages(system string, messages []chat.Message) ([]openai.ChatCompletionMessage, error) {
+	out := make([]openai.ChatCompletionMessage, 0, len(messages)+1)
+	if system != "" {
+		out = append(out, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleSystem,
+			Content: system,
+		})
+	}
+	for _, m := range messages {
+		role := openai.ChatMessageRoleUser
+		switch m.Role {
+		case chat.RoleSystem:
+			role = openai.ChatMessageRoleSystem
+		case chat.RoleAssistant:
+			role = openai.ChatMessageRoleAssistant
+		case chat.RoleTool:
+			role = openai.ChatMessageRoleTool
+		}
+		message := openai.ChatCompletionMessage{Role: role, Content: m.Content, ToolCallID: m.ToolCallID}
+		for _, call := range m.ToolCalls {
+			args := []byte("{}")
+			if call.Arguments != nil {
+				var err error
+				if args, err = json.Marshal(call.Arguments); err != nil {
+					return nil, err
+				}
+			}
+			message.ToolCalls = append(message.ToolCalls, openai.ToolCall{
+				ID:       call.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: call.Name, Arguments: string(args)},
+			})
+		}
+		if len(m.Parts) > 0 {
+			parts, err := toOpenAIParts(m)
+			if err != nil {
+				return nil, err
+			}
+			message.Content = ""
+			message.MultiContent = parts
+		}
+		out = append(out, message)
+	}
+	return out, nil
+}
+
+// toOpenAIParts maps the text and images of m to content parts. Inline
+// images are sent as data URLs; other media are not supported by chat
+// completions.
+func toOpenAIParts(m chat.Message) ([]openai.ChatMessagePart, error) {
+	parts := make([]openai.ChatMessagePart, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		parts = append(parts, openai.ChatMessagePart{Type: openai.ChatMessagePartTypeText, Text: m.Content})
+	}
+	for _, part := range m.Parts {
+		if !part.IsImage() {
+			return nil, fmt.Errorf("%w: openai accepts images only, got %s", provider.ErrUnsupportedContent, part.MIMEType)
+		}
+		url := part.URL
+		if url == "" {
+			url = "data:" + part.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(part.Data)
+		}
+		parts = append(parts, openai.ChatMessagePart{
+			Type:     openai.ChatMessagePartTypeImageURL,
+			ImageURL: &openai.ChatMessageImageURL{URL: url},
+		})
+	}
+	return parts, nil
 }
@@ -0,0 +1,25 @@
+package provider
+
+import "context"
+
+// Embedder is implemented by providers that serve embedding models.
+type Embedder interface {
+	Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error)
+}
+
+// EmbedRequest is a batch of texts to embed with one model.
+type EmbedRequest struct {
+	Model  string
+	Inputs []string
+	// Dimensions truncates the vectors, where the model supports it.
+	Dimensions *int32
+	// TaskType optimises the vectors for a use, e.g RETRIEVAL_QUERY. Only
+	// Gemini models use it.
+	TaskType string
+}
+
+// EmbedResponse holds one vector per input, in input order.
+type EmbedResponse struct {
+	Embeddings [][]float32
+	Usage      Usage
+}
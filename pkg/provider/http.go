@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// NewHTTPClient returns an HTTP client tuned for long-lived, highly
+// concurrent connections to a provider API. The default transport keeps only
+// two idle connections per host, so under load most requests would pay for a
+// new TCP and TLS handshake. No overall timeout is set because streamed
+// responses can legitimately take minutes; callers bound requests with their
+// context instead. Retry-After headers of failed responses are recorded for
+// RetryPolicy.Do.
+func NewHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &retryAfterTransport{base: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   10 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          256,
+			MaxIdleConnsPerHost:   64,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		}},
+	}
+}
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/imrany/wrapper/pkg/chat"
+)
+
+// ErrUnsupportedContent is returned for message parts a provider cannot
+// send, e.g a PDF to a backend that only accepts images.
+var ErrUnsupportedContent = errors.New("unsupported content")
+
+// Provider is a model backend the wrapper can route requests to. Backends
+// outside this repository plug in by implementing it and adding themselves
+// to a Registry.
+type Provider interface {
+	// Name is the unique, lower-case provider name, e.g "gemini".
+	Name() string
+
+	// Generate returns the complete response for req.
+	Generate(ctx context.Context, req *Request) (*Response, error)
+
+	// Stream calls onChunk with each piece of generated text as soon as it
+	// is available and returns the complete response once the stream ends.
+	// Returning an error from onChunk aborts the stream.
+	Stream(ctx context.Context, req *Request, onChunk func(string) error) (*Response, error)
+
+	// ListModels queries the backend for the models it can serve.
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+
+	// Capabilities reports what model supports.
+	Capabilities(model string) Capabilities
+}
+
+// Request is a provider-neutral generation request.
+type Request struct {
+	Model string
+	// SystemInstruction is sent ahead of any system messages.
+	SystemInstruction string
+	Messages          []chat.Message
+	Generation        GenerationConfig
+	// Tools the model may call instead of answering.
+	Tools []chat.Tool
+	// ResponseFormat constrains the response to JSON, nil means text.
+	ResponseFormat *ResponseFormat
+}
+
+// ResponseFormatType is the kind of output a ResponseFormat asks for.
+type ResponseFormatType string
+
+const (
+	// ResponseJSON asks for any JSON object.
+	ResponseJSON ResponseFormatType = "json_object"
+	// ResponseJSONSchema asks for JSON matching Schema.
+	ResponseJSONSchema ResponseFormatType = "json_schema"
+)
+
+// ResponseFormat asks the model for JSON output. Providers without native
+// support instruct the model instead, so callers should validate the result.
+type ResponseFormat struct {
+	Type ResponseFormatType
+	// Name identifies the schema, for providers that require one.
+	Name string
+	// Schema is the JSON Schema of the response, for ResponseJSONSchema.
+	Schema map[string]any
+}
+
+// GenerationConfig holds sampling and length controls. Unset fields leave
+// the provider's own default in place.
+type GenerationConfig struct {
+	Temperature     *float32 `mapstructure:"temperature"`
+	TopP            *float32 `mapstructure:"top_p"`
+	MaxOutputTokens *int32   `mapstructure:"max_output_tokens"`
+	StopSequences   []string `mapstructure:"stop_sequences"`
+	Seed            *int64   `mapstructure:"seed"`
+}
+
+// ValidSeed reports whether seed fits in the 32 bits Gemini accepts, so no
+// two seeds a client sends are narrowed to the same value.
+func ValidSeed(seed int64) bool {
+	return seed >= math.MinInt32 && seed <= math.MaxInt32
+}
+
+// Response is a provider-neutral generation result.
+type Response struct {
+	Text string
+	// ToolCalls requested by the model, FinishReason is FinishToolCalls.
+	ToolCalls    []chat.ToolCall
+	FinishReason FinishReason
+	Usage        Usage
+	// ModelVersion is the exact model version reported by the provider.
+	ModelVersion string
+}
+
+// FinishReason is the provider-neutral reason generation stopped.
+type FinishReason string
+
+const (
+	FinishStop      FinishReason = "stop"
+	FinishLength    FinishReason = "length"
+	FinishSafety    FinishReason = "safety"
+	FinishToolCalls FinishReason = "tool_calls"
+	FinishOther     FinishReason = "other"
+)
+
+// Usage holds the token counts of a request.
+type Usage struct {
+	PromptTokens     int32
+	CompletionTokens int32
+	TotalTokens      int32
+}
+
+// ModelInfo describes a model served by a provider.
+type ModelInfo struct {
+	ID            string
+	Provider      string
+	DisplayName   string
+	ContextWindow int
+}
+
+// Capabilities describes the optional features of a model.
+type Capabilities struct {
+	Streaming bool
+	Vision    bool
+	Tools     bool
+	JSONMode  bool
+}
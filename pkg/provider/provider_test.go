@@ -0,0 +1,27 @@
+package provider
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValidSeed(t *testing.T) {
+	tests := []struct {
+		seed int64
+		want bool
+	}{
+		{seed: 0, want: true},
+		{seed: 42, want: true},
+		{seed: math.MaxInt32, want: true},
+		{seed: math.MinInt32, want: true},
+		{seed: math.MaxInt32 + 1},
+		{seed: math.MinInt32 - 1},
+		// Would be narrowed to 42 by a plain int32 conversion.
+		{seed: 1<<32 + 42},
+	}
+	for _, tt := range tests {
+		if got := ValidSeed(tt.seed); got != tt.want {
+			t.Errorf("ValidSeed(%d) = %v, want %v", tt.seed, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedModel is returned when no registered provider serves a model.
+var ErrUnsupportedModel = errors.New("unsupported model")
+
+// Registry is the routing table from model names to providers. A model is
+// routed, in order, by alias, by an explicit "provider/model" name and by the
+// longest matching model name prefix. It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+	prefixes  map[string]Provider
+	aliases   map[string]string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		providers: make(map[string]Provider),
+		prefixes:  make(map[string]Provider),
+		aliases:   make(map[string]string),
+	}
+}
+
+// Register adds p and routes models starting with one of prefixes
+// (case-insensitive) to it, e.g "gemini-" or "ft:gpt-".
+func (r *Registry) Register(p Provider, prefixes ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := p.Name()
+	if _, ok := r.providers[name]; ok {
+		return fmt.Errorf("provider %q already registered", name)
+	}
+	for _, prefix := range prefixes {
+		if err := r.checkPrefix(prefix); err != nil {
+			return err
+		}
+	}
+
+	r.providers[name] = p
+	for _, prefix := range prefixes {
+		r.prefixes[strings.ToLower(prefix)] = p
+	}
+	return nil
+}
+
+// Route adds a prefix rule sending models starting with prefix to the
+// registered provider named name.
+func (r *Registry) Route(prefix, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.providers[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("route %q: provider %q is not registered", prefix, name)
+	}
+	if err := r.checkPrefix(prefix); err != nil {
+		return err
+	}
+	r.prefixes[strings.ToLower(prefix)] = p
+	return nil
+}
+
+func (r *Registry) checkPrefix(prefix string) error {
+	if prefix == "" {
+		return fmt.Errorf("model prefix cannot be empty")
+	}
+	if other, ok := r.prefixes[strings.ToLower(prefix)]; ok {
+		return fmt.Errorf("model prefix %q already served by provider %q", prefix, other.Name())
+	}
+	return nil
+}
+
+// Alias makes name an alternative name of model, e.g "fast" for
+// "gemini-2.5-flash". model must be routable and cannot be an alias itself.
+func (r *Registry) Alias(name, model string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := strings.ToLower(name)
+	if key == "" {
+		return fmt.Errorf("alias cannot be empty")
+	}
+	if target, ok := r.aliases[key]; ok {
+		return fmt.Errorf("alias %q already points to %q", name, target)
+	}
+	if _, ok := r.aliases[strings.ToLower(model)]; ok {
+		return fmt.Errorf("alias %q: target %q is an alias", name, model)
+	}
+	if _, _, err := r.route(model); err != nil {
+		return fmt.Errorf("alias %q: %w", name, err)
+	}
+	r.aliases[key] = model
+	return nil
+}
+
+// Lookup returns the model an alias points to.
+func (r *Registry) Lookup(alias string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.aliases[strings.ToLower(alias)]
+	return model, ok
+}
+
+// Aliases returns a copy of the aliases and the models they point to.
+func (r *Registry) Aliases() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	aliases := make(map[string]string, len(r.aliases))
+	for alias, model := range r.aliases {
+		aliases[alias] = model
+	}
+	return aliases
+}
+
+// Get returns the provider registered under name.
+func (r *Registry) Get(name string) (Provider, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Resolve returns the provider serving model (case-insensitive) and the
+// model name to send to it. Aliases are replaced by their model, and a
+// "provider/model" name, e.g "ollama/llama3", selects the provider
+// explicitly and strips the prefix. The error of an unroutable model wraps
+// ErrUnsupportedModel and lists the configured routes.
+func (r *Registry) Resolve(model string) (Provider, string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if target, ok := r.aliases[strings.ToLower(model)]; ok {
+		model = target
+	}
+	return r.route(model)
+}
+
+func (r *Registry) route(model string) (Provider, string, error) {
+	if name, upstream, ok := strings.Cut(model, "/"); ok {
+		if p, ok := r.providers[strings.ToLower(name)]; ok && upstream != "" {
+			return p, upstream, nil
+		}
+	}
+
+	var match string
+	lower := strings.ToLower(model)
+	for prefix := range r.prefixes {
+		if strings.HasPrefix(lower, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	if match != "" {
+		return r.prefixes[match], model, nil
+	}
+	return nil, "", fmt.Errorf("%w: %s (%s)", ErrUnsupportedModel, model, r.describe())
+}
+
+// describe summarises the routing table for error messages.
+func (r *Registry) describe() string {
+	var names []string
+	for name := range r.providers {
+		names = append(names, name+"/<model>")
+	}
+	sort.Strings(names)
+
+	var prefixes []string
+	for prefix, p := range r.prefixes {
+		prefixes = append(prefixes, fmt.Sprintf("%s* -> %s", prefix, p.Name()))
+	}
+	sort.Strings(prefixes)
+
+	var aliases []string
+	for alias, model := range r.aliases {
+		aliases = append(aliases, fmt.Sprintf("%s -> %s", alias, model))
+	}
+	sort.Strings(aliases)
+
+	var parts []string
+	for _, group := range []struct {
+		name  string
+		items []string
+	}{{"providers", names}, {"prefixes", prefixes}, {"aliases", aliases}} {
+		if len(group.items) > 0 {
+			parts = append(parts, group.name+": "+strings.Join(group.items, ", "))
+		}
+	}
+	if len(parts) == 0 {
+		return "no providers configured"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Providers returns all registered providers ordered by name.
+func (r *Registry) Providers() []Provider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	providers := make([]Provider, 0, len(r.providers))
+	for _, p := range r.providers {
+		providers = append(providers, p)
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Name() < providers[j].Name()
+	})
+	return providers
+}
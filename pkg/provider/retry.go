@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// RetryClassifier is implemented by providers that can tell transient
+// errors, worth retrying, from permanent ones. Errors of other providers
+// are only retried when IsTransient reports a network failure.
+type RetryClassifier interface {
+	Retryable(err error) bool
+}
+
+// RetryableStatus reports whether an HTTP status is worth retrying:
+// timeouts, conflicts, rate limits, server errors and overloads.
+func RetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout, 529:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsTransient reports whether err is a network failure that may not happen
+// again, e.g a reset connection or a dial timeout. Other network errors,
+// like TLS certificate failures, unknown hosts or bad URLs, will fail the
+// same way on every attempt.
+func IsTransient(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsTemporary && !dnsErr.IsNotFound {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// RetryPolicy controls how often and how long provider calls are retried.
+// Zero fields use the defaults of DefaultRetryPolicy.
+type RetryPolicy struct {
+	// MaxAttempts includes the first call, 1 disables retries.
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// InitialBackoff is the wait before the first retry, doubling up to
+	// MaxBackoff for each further one. A random jitter of up to half of it
+	// is subtracted so clients do not retry in lockstep.
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// DefaultRetryPolicy retries twice, after about 0.5s and 1s.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+	return p
+}
+
+// backoff returns the wait before retry n (0-based).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	wait := min(float64(p.MaxBackoff), float64(p.InitialBackoff)*math.Pow(2, float64(n)))
+	return time.Duration(wait/2 + rand.Float64()*wait/2)
+}
+
+// Do calls fn until it succeeds or fails with an error retryable rejects.
+// A Retry-After sent by the provider replaces the backoff, unless it is
+// longer than MaxBackoff. Do gives up early rather than wait past the
+// deadline of ctx, returning the last error. onRetry, if set, is called
+// before each wait.
+func (p RetryPolicy) Do(ctx context.Context, retryable func(error) bool, onRetry func(attempt int, wait time.Duration, err error), fn func(context.Context) error) error {
+	p = p.withDefaults()
+	for attempt := 1; ; attempt++ {
+		hint := &retryAfter{}
+		err := fn(context.WithValue(ctx, retryAfterKey{}, hint))
+		if err == nil || attempt >= p.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		wait := p.backoff(attempt - 1)
+		if after := hint.get(); after > 0 {
+			if after > p.MaxBackoff {
+				return err
+			}
+			wait = after
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+		if onRetry != nil {
+			onRetry(attempt, wait, err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+type retryAfterKey struct{}
+
+// retryAfter holds the wait a provider asked for in its last response.
+type retryAfter struct {
+	wait atomic.Int64
+}
+
+func (r *retryAfter) get() time.Duration {
+	return time.Duration(r.wait.Load())
+}
+
+// retryAfterTransport records the Retry-After header of failed responses
+// for RetryPolicy.Do, as the provider SDKs do not expose it in their errors.
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+	if hint, ok := req.Context().Value(retryAfterKey{}).(*retryAfter); ok {
+		if wait, ok := parseRetryAfter(resp.Header); ok {
+			hint.wait.Store(int64(wait))
+		}
+	}
+	return resp, err
+}
+
+// parseRetryAfter reads the retry-after-ms header of OpenAI and Anthropic,
+// or the standard Retry-After in seconds or as an HTTP date.
+func parseRetryAfter(header http.Header) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+	}
+	return 0, false
+}
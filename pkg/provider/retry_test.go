@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsTransient(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	// The certificate of the test server is not trusted by default.
+	_, certErr := http.Get(tlsServer.URL)
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+	_, timeoutErr := (&http.Client{Timeout: 10 * time.Millisecond}).Get(slow.URL)
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedURL := "http://" + closed.Addr().String()
+	closed.Close()
+	_, refusedErr := http.Get(closedURL)
+
+	_, schemeErr := http.Get("ftp://example.com/file")
+
+	urlError := func(err error) error {
+		return &url.Error{Op: "Post", URL: "https://api.example.com/v1", Err: err}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "timeout", err: timeoutErr, want: true},
+		{name: "connection refused", err: refusedErr, want: true},
+		{name: "connection reset", err: urlError(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), want: true},
+		{name: "unexpected eof", err: urlError(io.ErrUnexpectedEOF), want: true},
+		{name: "temporary dns failure", err: urlError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "server misbehaving", Name: "api.example.com", IsTemporary: true}}), want: true},
+		{name: "untrusted certificate", err: certErr, want: false},
+		{name: "unknown host", err: urlError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "api.example.invalid", IsNotFound: true}}), want: false},
+		{name: "unsupported scheme", err: schemeErr, want: false},
+		{name: "wrapped certificate", err: fmt.Errorf("generation: %w", certErr), want: false},
+		{name: "other", err: errors.New("invalid argument"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err == nil {
+				t.Fatal("setup did not produce an error")
+			}
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Fatalf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// TokenCounter is implemented by providers that can count the input tokens
+// of a request exactly. Other providers are estimated with EstimateTokens.
+type TokenCounter interface {
+	CountTokens(ctx context.Context, req *Request) (int32, error)
+}
+
+// Per-message overhead of chat formats, as documented for OpenAI models.
+const (
+	tokensPerMessage = 3
+	tokensPerReply   = 3
+)
+
+// tokensPerPart approximates an image or document page, as Gemini counts a
+// standard size image.
+const tokensPerPart = 258
+
+// EstimateTokens estimates the input tokens of req with the BPE tokenizer of
+// its model, plus the chat format overhead of every message. Models that are
+// not OpenAI's are counted with o200k_base, which is close for other BPE
+// tokenizers.
+func EstimateTokens(req *Request) int32 {
+	count := tokenCounter(req.Model)
+	tokens := tokensPerReply
+	if req.SystemInstruction != "" {
+		tokens += tokensPerMessage + count(req.SystemInstruction)
+	}
+	for _, m := range req.Messages {
+		tokens += tokensPerMessage + count(m.Content) + len(m.Parts)*tokensPerPart
+		for _, call := range m.ToolCalls {
+			tokens += count(call.Name) + countJSON(count, call.Arguments)
+		}
+	}
+	return int32(tokens) + EstimateToolTokens(req)
+}
+
+// EstimateToolTokens estimates the tokens of the tool declarations of req,
+// for token counters that cannot count them.
+func EstimateToolTokens(req *Request) int32 {
+	count := tokenCounter(req.Model)
+	tokens := 0
+	for _, t := range req.Tools {
+		tokens += count(t.Name) + count(t.Description) + countJSON(count, t.Parameters)
+	}
+	return int32(tokens)
+}
+
+// countJSON counts v as it is sent to providers, JSON encoded.
+func countJSON(count func(string) int, v map[string]any) int {
+	if len(v) == 0 {
+		return 0
+	}
+	b, _ := json.Marshal(v)
+	return count(string(b))
+}
+
+// tokenCounter returns a function counting the tokens of a text for model,
+// or estimateText when the encodings could not be loaded.
+func tokenCounter(model string) func(string) int {
+	encoding := tiktoken.MODEL_O200K_BASE
+	if name, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+		encoding = name
+	} else if strings.HasPrefix(model, "gpt-4-") || strings.HasPrefix(model, "gpt-3.5-turbo") {
+		encoding = tiktoken.MODEL_CL100K_BASE
+	}
+
+	enc, ok := loadEncodings()[encoding]
+	if !ok {
+		return estimateText
+	}
+	return func(text string) int {
+		if text == "" {
+			return 0
+		}
+		return len(enc.EncodeOrdinary(text))
+	}
+}
+
+// estimateText approximates BPE tokenizers at four ASCII characters per
+// token. Other characters, e.g CJK, are mostly split into one or more
+// tokens each, so each counts as one.
+func estimateText(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < 0x80 {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// tokenizerTimeout bounds the download of the encodings.
+const tokenizerTimeout = time.Minute
+
+var (
+	encodingsOnce sync.Once
+	encodings     map[string]*tiktoken.Tiktoken
+	encodingsErr  error
+)
+
+// LoadTokenizers loads the BPE encodings used by EstimateTokens, from
+// TIKTOKEN_CACHE_DIR or else downloading them once. It is called at startup
+// so every estimate uses the same tokenizer; offline hosts need the files
+// in TIKTOKEN_CACHE_DIR.
+func LoadTokenizers() error {
+	loadEncodings()
+	return encodingsErr
+}
+
+func loadEncodings() map[string]*tiktoken.Tiktoken {
+	encodingsOnce.Do(func() {
+		type result struct {
+			encodings map[string]*tiktoken.Tiktoken
+			err       error
+		}
+		// tiktoken downloads without a timeout, so give up on it instead.
+		done := make(chan result, 1)
+		go func() {
+			loaded := make(map[string]*tiktoken.Tiktoken)
+			for _, name := range []string{tiktoken.MODEL_O200K_BASE, tiktoken.MODEL_CL100K_BASE} {
+				enc, err := tiktoken.GetEncoding(name)
+				if err != nil {
+					done <- result{err: fmt.Errorf("failed to load the %s tokenizer (set TIKTOKEN_CACHE_DIR to a directory holding it on offline hosts): %w", name, err)}
+					return
+				}
+				loaded[name] = enc
+			}
+			done <- result{encodings: loaded}
+		}()
+
+		select {
+		case r := <-done:
+			encodings, encodingsErr = r.encodings, r.err
+		case <-time.After(tokenizerTimeout):
+			encodingsErr = fmt.Errorf("timed out loading the tokenizers (set TIKTOKEN_CACHE_DIR to a directory holding them on offline hosts)")
+		}
+	})
+	return encodings
+}
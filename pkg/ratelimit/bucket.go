@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"time"
+)
+
+// bucket is a token bucket holding up to capacity tokens, refilled at rate
+// tokens per second. Charges may take it below zero, which delays the next
+// request until the debt is refilled.
+type bucket struct {
+	capacity float64
+	rate     float64
+	tokens   float64
+	updated  time.Time
+}
+
+// newBucket returns a full bucket refilling capacity tokens every period.
+func newBucket(capacity int64, period time.Duration, now time.Time) *bucket {
+	return &bucket{
+		capacity: float64(capacity),
+		rate:     float64(capacity) / period.Seconds(),
+		tokens:   float64(capacity),
+		updated:  now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = min(b.capacity, b.tokens+elapsed*b.rate)
+		b.updated = now
+	}
+}
+
+// wait returns how long until n tokens are available, zero if they are.
+func (b *bucket) wait(now time.Time, n float64) time.Duration {
+	b.refill(now)
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *bucket) take(now time.Time, n float64) {
+	b.refill(now)
+	b.tokens -= n
+}
+
+func (b *bucket) full(now time.Time) bool {
+	b.refill(now)
+	return b.tokens >= b.capacity
+}
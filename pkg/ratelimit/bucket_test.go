@@ -0,0 +1,49 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	b := newBucket(60, time.Minute, start)
+
+	if wait := b.wait(start, 60); wait != 0 {
+		t.Fatalf("full bucket wait = %v, want 0", wait)
+	}
+	b.take(start, 60)
+	if wait := b.wait(start, 1); wait != time.Second {
+		t.Fatalf("empty bucket wait = %v, want 1s", wait)
+	}
+
+	// Refills at one token per second.
+	if wait := b.wait(start.Add(500*time.Millisecond), 1); wait != 500*time.Millisecond {
+		t.Fatalf("half refilled wait = %v, want 500ms", wait)
+	}
+	if wait := b.wait(start.Add(time.Second), 1); wait != 0 {
+		t.Fatalf("refilled wait = %v, want 0", wait)
+	}
+
+	// Charges may go below zero, delaying the next request until repaid.
+	now := start.Add(time.Second)
+	b.take(now, 11)
+	if wait := b.wait(now, 1); wait != 11*time.Second {
+		t.Fatalf("in debt wait = %v, want 11s", wait)
+	}
+
+	// Refilling never exceeds the capacity.
+	later := now.Add(time.Hour)
+	if !b.full(later) {
+		t.Fatal("bucket not full after an hour")
+	}
+	if b.tokens != b.capacity {
+		t.Fatalf("tokens = %v, want capacity %v", b.tokens, b.capacity)
+	}
+
+	// A clock going backwards does not refill.
+	b.take(later, 1)
+	if b.full(later.Add(-time.Minute)) {
+		t.Fatal("bucket refilled by an earlier time")
+	}
+}
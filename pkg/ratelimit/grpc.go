@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/imrany/wrapper/pkg/auth"
+	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
+)
+
+// usageReporter is implemented by the responses that report token usage.
+type usageReporter interface {
+	GetUsage() *v1pb.Usage
+}
+
+// UnaryServerInterceptor rejects calls over the limits of their client and
+// charges the tokens of the others. It must run after authentication.
+func UnaryServerInterceptor(l *Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		key, client := l.identify(ctx)
+		if err := l.Allow(key, client); err != nil {
+			return nil, exceeded(err, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) })
+		}
+
+		resp, err := handler(ctx, req)
+		if r, ok := resp.(usageReporter); ok {
+			l.Charge(key, int64(r.GetUsage().GetTotalTokens()))
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming calls,
+// charging the usage reported by the last message that has one.
+func StreamServerInterceptor(l *Limiter) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		key, client := l.identify(stream.Context())
+		if err := l.Allow(key, client); err != nil {
+			return exceeded(err, stream.SetHeader)
+		}
+
+		counted := &usageStream{ServerStream: stream}
+		err := handler(srv, counted)
+		l.Charge(key, counted.tokens)
+		return err
+	}
+}
+
+// exceeded converts a limiter error to ResourceExhausted, telling the client
+// when to retry in a RetryInfo detail and the retry-after header, which the
+// REST gateway returns as Retry-After.
+func exceeded(err error, setHeader func(metadata.MD) error) error {
+	var limit *ExceededError
+	if !errors.As(err, &limit) {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	seconds := int64(math.Ceil(limit.RetryAfter.Seconds()))
+	_ = setHeader(metadata.Pairs("retry-after", strconv.FormatInt(seconds, 10)))
+
+	s := status.New(codes.ResourceExhausted, limit.Error())
+	if detailed, err := s.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(limit.RetryAfter)}); err == nil {
+		s = detailed
+	}
+	return s.Err()
+}
+
+// identify returns the bucket key of the caller: its authenticated client
+// name, or else its IP address. While the address is a trusted proxy, like
+// the local REST gateway, the client is looked up in X-Forwarded-For from
+// right to left, as each proxy appends the address it was called from.
+// Entries left of the first untrusted one may be forged by the client.
+func (l *Limiter) identify(ctx context.Context) (key, client string) {
+	if p, ok := auth.FromContext(ctx); ok {
+		return "client:" + p.Client, p.Client
+	}
+
+	var ip netip.Addr
+	if p, ok := peer.FromContext(ctx); ok {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		ip, _ = netip.ParseAddr(host)
+	}
+
+	var hops []string
+	for _, forwarded := range metadata.ValueFromIncomingContext(ctx, "x-forwarded-for") {
+		hops = append(hops, strings.Split(forwarded, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0 && (!ip.IsValid() || l.trustedProxy(ip.Unmap())); i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		ip = hop
+	}
+	return "ip:" + ip.Unmap().String(), ""
+}
+
+// usageStream records the token usage of the messages sent on a stream.
+type usageStream struct {
+	grpc.ServerStream
+	tokens int64
+}
+
+func (s *usageStream) SendMsg(m any) error {
+	if r, ok := m.(usageReporter); ok && r.GetUsage() != nil {
+		s.tokens = int64(r.GetUsage().GetTotalTokens())
+	}
+	return s.ServerStream.SendMsg(m)
+}
@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/imrany/wrapper/pkg/auth"
+	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
+)
+
+func TestIdentify(t *testing.T) {
+	l, err := New(Config{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded []string
+		want      string
+	}{
+		{name: "direct", peer: "203.0.113.7:4000", want: "ip:203.0.113.7"},
+		{name: "direct ignores forwarded", peer: "203.0.113.7:4000", forwarded: []string{"198.51.100.1"}, want: "ip:203.0.113.7"},
+		{name: "gateway", peer: "127.0.0.1:4000", forwarded: []string{"203.0.113.7"}, want: "ip:203.0.113.7"},
+		{name: "nginx and gateway", peer: "127.0.0.1:4000", forwarded: []string{"203.0.113.7, 127.0.0.1"}, want: "ip:203.0.113.7"},
+		{name: "forged entry", peer: "127.0.0.1:4000", forwarded: []string{"198.51.100.1, 203.0.113.7, 127.0.0.1"}, want: "ip:203.0.113.7"},
+		{name: "trusted range", peer: "10.1.2.3:4000", forwarded: []string{"203.0.113.7, 10.0.0.9"}, want: "ip:203.0.113.7"},
+		{name: "trusted address", peer: "192.0.2.1:4000", forwarded: []string{"203.0.113.7"}, want: "ip:203.0.113.7"},
+		{name: "untrusted proxy", peer: "127.0.0.1:4000", forwarded: []string{"203.0.113.7, 192.0.2.2"}, want: "ip:192.0.2.2"},
+		{name: "several headers", peer: "127.0.0.1:4000", forwarded: []string{"203.0.113.7", "127.0.0.1"}, want: "ip:203.0.113.7"},
+		{name: "garbage", peer: "127.0.0.1:4000", forwarded: []string{"unknown, 127.0.0.1"}, want: "ip:127.0.0.1"},
+		{name: "only proxies", peer: "127.0.0.1:4000", forwarded: []string{"10.0.0.9, 127.0.0.1"}, want: "ip:10.0.0.9"},
+		{name: "ipv6", peer: "[::1]:4000", forwarded: []string{"2001:db8::7"}, want: "ip:2001:db8::7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := net.ResolveTCPAddr("tcp", tt.peer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+			md := metadata.MD{}
+			for _, f := range tt.forwarded {
+				md.Append("x-forwarded-for", f)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			if key, _ := l.identify(ctx); key != tt.want {
+				t.Fatalf("key = %q, want %q", key, tt.want)
+			}
+		})
+	}
+
+	ctx := auth.NewContext(context.Background(), &auth.Principal{Client: "acme"})
+	if key, client := l.identify(ctx); key != "client:acme" || client != "acme" {
+		t.Fatalf("authenticated: key, client = %q, %q", key, client)
+	}
+}
+
+func TestNewTrustedProxies(t *testing.T) {
+	for _, proxy := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.1:80"} {
+		if _, err := New(Config{TrustedProxies: []string{proxy}}); err == nil {
+			t.Errorf("New with trusted proxy %q succeeded", proxy)
+		}
+	}
+}
+
+// listServer answers ListModels with no models.
+type listServer struct {
+	v1pb.UnimplementedAiServiceServer
+}
+
+func (listServer) ListModels(context.Context, *v1pb.ListModelsRequest) (*v1pb.ListModelsResponse, error) {
+	return &v1pb.ListModelsResponse{}, nil
+}
+
+// TestGatewayChain limits anonymous clients behind nginx and the REST
+// gateway by their own address, not by the proxies' loopback address.
+func TestGatewayChain(t *testing.T) {
+	l, err := New(Config{Limits: Limits{RequestsPerMinute: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(UnaryServerInterceptor(l)))
+	v1pb.RegisterAiServiceServer(server, listServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	gw := runtime.NewServeMux()
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if err := v1pb.RegisterAiServiceHandlerFromEndpoint(ctx, gw, lis.Addr().String(), opts); err != nil {
+		t.Fatal(err)
+	}
+	gateway := httptest.NewServer(gw)
+	defer gateway.Close()
+
+	// nginx on the same host appends the client's address to
+	// X-Forwarded-For, then the gateway appends nginx's, 127.0.0.1.
+	get := func(client string) int {
+		req, err := http.NewRequest(http.MethodGet, gateway.URL+"/v1/models", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Forwarded-For", client)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	steps := []struct {
+		forwarded string
+		want      int
+	}{
+		{forwarded: "203.0.113.7", want: http.StatusOK},
+		{forwarded: "198.51.100.1", want: http.StatusOK},
+		{forwarded: "203.0.113.7", want: http.StatusTooManyRequests},
+		// A forged entry left of the one nginx added does not help.
+		{forwarded: strings.Join([]string{"192.0.2.9", "203.0.113.7"}, ", "), want: http.StatusTooManyRequests},
+	}
+	for i, step := range steps {
+		if got := get(step.forwarded); got != step.want {
+			t.Fatalf("request %d from %s: status = %d, want %d", i+1, step.forwarded, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Package ratelimit limits the requests and tokens each client of the
+// wrapper may use, so one client cannot exhaust the shared provider quota.
+package ratelimit
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits are the allowances of a client, zero means unlimited.
+type Limits struct {
+	RequestsPerMinute int64 `mapstructure:"requests_per_minute"`
+	TokensPerDay      int64 `mapstructure:"tokens_per_day"`
+}
+
+// ClientLimits override the default limits of an authenticated client.
+type ClientLimits struct {
+	Client string `mapstructure:"client"`
+	Limits `mapstructure:",squash"`
+}
+
+// Config holds the default limits of every client and per-client overrides.
+type Config struct {
+	Limits  `mapstructure:",squash"`
+	Clients []ClientLimits `mapstructure:"clients"`
+
+	// TrustedProxies are the addresses or CIDR ranges of the proxies in
+	// front of the server, e.g a load balancer, whose X-Forwarded-For
+	// entries are skipped to find the client. Loopback addresses, such as
+	// the REST gateway and a local nginx, are always trusted.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+// Enabled reports whether any limit is configured.
+func (c Config) Enabled() bool {
+	if c.RequestsPerMinute > 0 || c.TokensPerDay > 0 {
+		return true
+	}
+	for _, client := range c.Clients {
+		if client.RequestsPerMinute > 0 || client.TokensPerDay > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ExceededError is returned for a request over one of the client's limits.
+type ExceededError struct {
+	// Limit describes the exceeded limit, e.g "60 requests per minute".
+	Limit string
+	// RetryAfter is how long until the request would be allowed.
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("rate limit of %s exceeded, retry in %s", e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+// sweepInterval is how often the buckets of idle clients are dropped.
+const sweepInterval = time.Minute
+
+// Limiter tracks the request and token buckets of each client.
+type Limiter struct {
+	defaults Limits
+	clients  map[string]Limits
+	trusted  []netip.Prefix
+	now      func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*buckets
+	swept   time.Time
+}
+
+// buckets of a client, nil when the limit is disabled.
+type buckets struct {
+	requests *bucket
+	tokens   *bucket
+}
+
+// New returns a Limiter enforcing config.
+func New(config Config) (*Limiter, error) {
+	clients := make(map[string]Limits, len(config.Clients))
+	for _, c := range config.Clients {
+		clients[strings.ToLower(c.Client)] = c.Limits
+	}
+	trusted := make([]netip.Prefix, 0, len(config.TrustedProxies))
+	for _, proxy := range config.TrustedProxies {
+		prefix, err := parsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("rate_limits.trusted_proxies: %w", err)
+		}
+		trusted = append(trusted, prefix)
+	}
+	return &Limiter{
+		defaults: config.Limits,
+		clients:  clients,
+		trusted:  trusted,
+		now:      time.Now,
+		buckets:  make(map[string]*buckets),
+	}, nil
+}
+
+// parsePrefix parses a CIDR range or a single address.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// trustedProxy reports whether the X-Forwarded-For entries added by ip can
+// be believed.
+func (l *Limiter) trustedProxy(ip netip.Addr) bool {
+	if ip.IsLoopback() {
+		return true
+	}
+	for _, prefix := range l.trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow takes a request from the bucket of key, or returns an
+// *ExceededError. Client is the authenticated client name, if any, whose
+// overrides apply. The token quota only has to be positive, as the tokens
+// of a request are known once it completes.
+func (l *Limiter) Allow(key, client string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	b, limits := l.bucketsFor(key, client, now)
+
+	var exceeded *ExceededError
+	if b.requests != nil {
+		if wait := b.requests.wait(now, 1); wait > 0 {
+			exceeded = &ExceededError{Limit: fmt.Sprintf("%d requests per minute", limits.RequestsPerMinute), RetryAfter: wait}
+		}
+	}
+	if b.tokens != nil {
+		if wait := b.tokens.wait(now, 1); wait > 0 && (exceeded == nil || wait > exceeded.RetryAfter) {
+			exceeded = &ExceededError{Limit: fmt.Sprintf("%d tokens per day", limits.TokensPerDay), RetryAfter: wait}
+		}
+	}
+	if exceeded != nil {
+		return exceeded
+	}
+
+	if b.requests != nil {
+		b.requests.take(now, 1)
+	}
+	return nil
+}
+
+// Charge takes the tokens used by a completed request from the quota of key.
+func (l *Limiter) Charge(key string, tokens int64) {
+	if tokens <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[key]; ok && b.tokens != nil {
+		b.tokens.take(l.now(), float64(tokens))
+	}
+}
+
+func (l *Limiter) bucketsFor(key, client string, now time.Time) (*buckets, Limits) {
+	limits, ok := l.clients[strings.ToLower(client)]
+	if !ok || client == "" {
+		limits = l.defaults
+	}
+	if b, ok := l.buckets[key]; ok {
+		return b, limits
+	}
+
+	b := &buckets{}
+	if limits.RequestsPerMinute > 0 {
+		b.requests = newBucket(limits.RequestsPerMinute, time.Minute, now)
+	}
+	if limits.TokensPerDay > 0 {
+		b.tokens = newBucket(limits.TokensPerDay, 24*time.Hour, now)
+	}
+	l.buckets[key] = b
+	return b, limits
+}
+
+// sweep drops full buckets, which are the same as new ones, so clients
+// identified by IP do not accumulate forever.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < sweepInterval {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		if (b.requests == nil || b.requests.full(now)) && (b.tokens == nil || b.tokens.full(now)) {
+			delete(l.buckets, key)
+		}
+	}
+}
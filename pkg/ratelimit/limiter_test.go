@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterRequests(t *testing.T) {
+	l, clock := newTestLimiter(Config{Limits: Limits{RequestsPerMinute: 2}})
+
+	for i := range 2 {
+		if err := l.Allow("ip:a", ""); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	err := l.Allow("ip:a", "")
+	var exceeded *ExceededError
+	if !errors.As(err, &exceeded) {
+		t.Fatalf("third request: err = %v, want *ExceededError", err)
+	}
+	if exceeded.Limit != "2 requests per minute" || exceeded.RetryAfter != 30*time.Second {
+		t.Fatalf("exceeded = %+v, want 2 requests per minute, retry in 30s", exceeded)
+	}
+
+	// Other keys have their own bucket.
+	if err := l.Allow("ip:b", ""); err != nil {
+		t.Fatalf("other key: %v", err)
+	}
+
+	*clock = clock.Add(30 * time.Second)
+	if err := l.Allow("ip:a", ""); err != nil {
+		t.Fatalf("after refill: %v", err)
+	}
+}
+
+func TestLimiterTokens(t *testing.T) {
+	l, clock := newTestLimiter(Config{Limits: Limits{TokensPerDay: 86_400}})
+
+	if err := l.Allow("client:acme", "acme"); err != nil {
+		t.Fatal(err)
+	}
+	l.Charge("client:acme", 86_400+60)
+
+	var exceeded *ExceededError
+	if err := l.Allow("client:acme", "acme"); !errors.As(err, &exceeded) {
+		t.Fatalf("over quota: err = %v, want *ExceededError", err)
+	}
+	if exceeded.Limit != "86400 tokens per day" || exceeded.RetryAfter != 61*time.Second {
+		t.Fatalf("exceeded = %+v, want 86400 tokens per day, retry in 61s", exceeded)
+	}
+
+	*clock = clock.Add(61 * time.Second)
+	if err := l.Allow("client:acme", "acme"); err != nil {
+		t.Fatalf("after refill: %v", err)
+	}
+
+	// Charges of unknown keys and empty charges are ignored.
+	l.Charge("client:unknown", 100)
+	l.Charge("client:acme", 0)
+}
+
+func TestLimiterClientOverrides(t *testing.T) {
+	l, _ := newTestLimiter(Config{
+		Limits:  Limits{RequestsPerMinute: 1},
+		Clients: []ClientLimits{{Client: "Acme", Limits: Limits{RequestsPerMinute: 3}}},
+	})
+
+	tests := []struct {
+		key, client string
+		allowed     int
+	}{
+		{key: "client:acme", client: "acme", allowed: 3},
+		{key: "client:beta", client: "beta", allowed: 1},
+		// Overrides only apply to authenticated clients.
+		{key: "ip:acme", client: "", allowed: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			for i := range tt.allowed {
+				if err := l.Allow(tt.key, tt.client); err != nil {
+					t.Fatalf("request %d: %v", i+1, err)
+				}
+			}
+			if err := l.Allow(tt.key, tt.client); err == nil {
+				t.Fatalf("request %d allowed, want the limit of %d", tt.allowed+1, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	l, clock := newTestLimiter(Config{Limits: Limits{RequestsPerMinute: 60}})
+
+	for _, key := range []string{"ip:a", "ip:b"} {
+		if err := l.Allow(key, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(l.buckets) != 2 {
+		t.Fatalf("buckets = %d, want 2", len(l.buckets))
+	}
+
+	// After a minute both buckets are full again and are dropped, except
+	// the one of the request that triggers the sweep.
+	*clock = clock.Add(sweepInterval)
+	if err := l.Allow("ip:c", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := l.buckets["ip:c"]; !ok || len(l.buckets) != 1 {
+		t.Fatalf("buckets after sweep = %v, want only ip:c", l.buckets)
+	}
+}
+
+func TestConfigEnabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   bool
+	}{
+		{name: "empty", config: Config{}},
+		{name: "requests", config: Config{Limits: Limits{RequestsPerMinute: 1}}, want: true},
+		{name: "tokens", config: Config{Limits: Limits{TokensPerDay: 1}}, want: true},
+		{name: "client", config: Config{Clients: []ClientLimits{{Client: "acme", Limits: Limits{TokensPerDay: 1}}}}, want: true},
+		{name: "client without limits", config: Config{Clients: []ClientLimits{{Client: "acme"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.Enabled(); got != tt.want {
+				t.Fatalf("Enabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestLimiter returns a Limiter whose clock only moves when the returned
+// time is changed.
+func newTestLimiter(config Config) (*Limiter, *time.Time) {
+	clock := time.Unix(1_700_000_000, 0)
+	l, err := New(config)
+	if err != nil {
+		panic(err)
+	}
+	l.now = func() time.Time { return clock }
+	return l, &clock
+}
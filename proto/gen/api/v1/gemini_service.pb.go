@@ -11,6 +11,7 @@ import (
 	status "google.golang.org/genproto/googleapis/rpc/status"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -25,27 +26,1018 @@ const (
 
 type GenAiRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// User prompt for AI generation
-	Prompt        string `protobuf:"bytes,1,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	// User prompt for AI generation. When messages are also set it is
+	// appended to the conversation as the final user turn.
+	Prompt string `protobuf:"bytes,1,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	// Conversation history, oldest first
+	Messages []*Message `protobuf:"bytes,2,rep,name=messages,proto3" json:"messages,omitempty"`
+	// Model to use instead of the deployment default, e.g gemini-2.5-flash.
+	// Must be one of the models allowed in the config file.
+	Model string `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	// Sampling and length controls, unset fields use the server defaults
+	GenerationConfig *GenerationConfig `protobuf:"bytes,4,opt,name=generation_config,json=generationConfig,proto3" json:"generation_config,omitempty"`
+	// System prompt steering the model. Replaces the configured default
+	// system prompt of the model or deployment.
+	SystemInstruction string `protobuf:"bytes,5,opt,name=system_instruction,json=systemInstruction,proto3" json:"system_instruction,omitempty"`
+	// Images or documents sent with the prompt
+	Parts []*Part `protobuf:"bytes,6,rep,name=parts,proto3" json:"parts,omitempty"`
+	// Functions the model may call instead of answering
+	Tools []*Tool `protobuf:"bytes,7,rep,name=tools,proto3" json:"tools,omitempty"`
+	// Constrains the response to JSON, validated before it is returned
+	ResponseFormat *ResponseFormat `protobuf:"bytes,8,opt,name=response_format,json=responseFormat,proto3" json:"response_format,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GenAiRequest) Reset() {
+	*x = GenAiRequest{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenAiRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenAiRequest) ProtoMessage() {}
+
+func (x *GenAiRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenAiRequest.ProtoReflect.Descriptor instead.
+func (*GenAiRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GenAiRequest) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *GenAiRequest) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *GenAiRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *GenAiRequest) GetGenerationConfig() *GenerationConfig {
+	if x != nil {
+		return x.GenerationConfig
+	}
+	return nil
+}
+
+func (x *GenAiRequest) GetSystemInstruction() string {
+	if x != nil {
+		return x.SystemInstruction
+	}
+	return ""
+}
+
+func (x *GenAiRequest) GetParts() []*Part {
+	if x != nil {
+		return x.Parts
+	}
+	return nil
+}
+
+func (x *GenAiRequest) GetTools() []*Tool {
+	if x != nil {
+		return x.Tools
+	}
+	return nil
+}
+
+func (x *GenAiRequest) GetResponseFormat() *ResponseFormat {
+	if x != nil {
+		return x.ResponseFormat
+	}
+	return nil
+}
+
+type ResponseFormat struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "text" (default), "json_object" for any JSON object or "json_schema"
+	// for JSON matching schema
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// Name of the schema: letters, digits, underscores and dashes
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// JSON Schema of the response, required for "json_schema"
+	Schema        *structpb.Struct `protobuf:"bytes,3,opt,name=schema,proto3" json:"schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResponseFormat) Reset() {
+	*x = ResponseFormat{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResponseFormat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResponseFormat) ProtoMessage() {}
+
+func (x *ResponseFormat) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResponseFormat.ProtoReflect.Descriptor instead.
+func (*ResponseFormat) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ResponseFormat) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ResponseFormat) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ResponseFormat) GetSchema() *structpb.Struct {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+type Tool struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Function name: letters, digits, underscores and dashes, at most 64
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// What the function does, helps the model decide when to call it
+	Description string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// JSON Schema of the arguments object
+	Parameters    *structpb.Struct `protobuf:"bytes,3,opt,name=parameters,proto3" json:"parameters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tool) Reset() {
+	*x = Tool{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tool) ProtoMessage() {}
+
+func (x *Tool) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tool.ProtoReflect.Descriptor instead.
+func (*Tool) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Tool) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tool) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Tool) GetParameters() *structpb.Struct {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+type ToolCall struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Identifies the call, echoed by the tool message with its result
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Name of the function to call
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Arguments object, matching the function's parameters schema
+	Arguments     *structpb.Struct `protobuf:"bytes,3,opt,name=arguments,proto3" json:"arguments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCall) Reset() {
+	*x = ToolCall{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCall) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCall) ProtoMessage() {}
+
+func (x *ToolCall) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCall.ProtoReflect.Descriptor instead.
+func (*ToolCall) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ToolCall) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ToolCall) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ToolCall) GetArguments() *structpb.Struct {
+	if x != nil {
+		return x.Arguments
+	}
+	return nil
+}
+
+type GenerationConfig struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Sampling temperature, higher values give more random output
+	Temperature *float32 `protobuf:"fixed32,1,opt,name=temperature,proto3,oneof" json:"temperature,omitempty"`
+	// Nucleus sampling: only tokens within this probability mass are considered
+	TopP *float32 `protobuf:"fixed32,2,opt,name=top_p,json=topP,proto3,oneof" json:"top_p,omitempty"`
+	// Maximum number of tokens to generate
+	MaxOutputTokens *int32 `protobuf:"varint,3,opt,name=max_output_tokens,json=maxOutputTokens,proto3,oneof" json:"max_output_tokens,omitempty"`
+	// Generation stops when any of these sequences is produced
+	StopSequences []string `protobuf:"bytes,4,rep,name=stop_sequences,json=stopSequences,proto3" json:"stop_sequences,omitempty"`
+	// Seed for reproducible sampling, where the provider supports it,
+	// between -2^31 and 2^31-1
+	Seed          *int64 `protobuf:"varint,5,opt,name=seed,proto3,oneof" json:"seed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerationConfig) Reset() {
+	*x = GenerationConfig{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerationConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerationConfig) ProtoMessage() {}
+
+func (x *GenerationConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerationConfig.ProtoReflect.Descriptor instead.
+func (*GenerationConfig) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GenerationConfig) GetTemperature() float32 {
+	if x != nil && x.Temperature != nil {
+		return *x.Temperature
+	}
+	return 0
+}
+
+func (x *GenerationConfig) GetTopP() float32 {
+	if x != nil && x.TopP != nil {
+		return *x.TopP
+	}
+	return 0
+}
+
+func (x *GenerationConfig) GetMaxOutputTokens() int32 {
+	if x != nil && x.MaxOutputTokens != nil {
+		return *x.MaxOutputTokens
+	}
+	return 0
+}
+
+func (x *GenerationConfig) GetStopSequences() []string {
+	if x != nil {
+		return x.StopSequences
+	}
+	return nil
+}
+
+func (x *GenerationConfig) GetSeed() int64 {
+	if x != nil && x.Seed != nil {
+		return *x.Seed
+	}
+	return 0
+}
+
+type Message struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Author of the message: "system", "user", "assistant" or "tool"
+	Role string `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	// Text content of the message, may be empty when parts are set
+	Content string `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	// Images or documents, following the text content
+	Parts []*Part `protobuf:"bytes,3,rep,name=parts,proto3" json:"parts,omitempty"`
+	// Calls requested by an assistant message, as returned in tool_calls
+	ToolCalls []*ToolCall `protobuf:"bytes,4,rep,name=tool_calls,json=toolCalls,proto3" json:"tool_calls,omitempty"`
+	// Call answered by a tool message, whose content is the result
+	ToolCallId    string `protobuf:"bytes,5,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Message) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Message) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Message) GetParts() []*Part {
+	if x != nil {
+		return x.Parts
+	}
+	return nil
+}
+
+func (x *Message) GetToolCalls() []*ToolCall {
+	if x != nil {
+		return x.ToolCalls
+	}
+	return nil
+}
+
+func (x *Message) GetToolCallId() string {
+	if x != nil {
+		return x.ToolCallId
+	}
+	return ""
+}
+
+type Part struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// MIME type, e.g image/png, image/jpeg, image/webp or application/pdf
+	MimeType string `protobuf:"bytes,1,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	// Types that are valid to be assigned to Source:
+	//
+	//	*Part_Data
+	//	*Part_Url
+	Source        isPart_Source `protobuf_oneof:"source"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Part) Reset() {
+	*x = Part{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Part) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Part) ProtoMessage() {}
+
+func (x *Part) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Part.ProtoReflect.Descriptor instead.
+func (*Part) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Part) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Part) GetSource() isPart_Source {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *Part) GetData() []byte {
+	if x != nil {
+		if x, ok := x.Source.(*Part_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+func (x *Part) GetUrl() string {
+	if x != nil {
+		if x, ok := x.Source.(*Part_Url); ok {
+			return x.Url
+		}
+	}
+	return ""
+}
+
+type isPart_Source interface {
+	isPart_Source()
+}
+
+type Part_Data struct {
+	// Inline content, base64 encoded in JSON
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3,oneof"`
+}
+
+type Part_Url struct {
+	// http(s) URL the provider fetches, or a gs:// URI for Gemini on
+	// Vertex AI
+	Url string `protobuf:"bytes,3,opt,name=url,proto3,oneof"`
+}
+
+func (*Part_Data) isPart_Source() {}
+
+func (*Part_Url) isPart_Source() {}
+
+type GenAiResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Original user prompt
+	Prompt string `protobuf:"bytes,1,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	// AI generated response
+	Response string `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	// Optional: structured error info
+	Status *status.Status `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// Token usage reported by the provider
+	Usage *Usage `protobuf:"bytes,4,opt,name=usage,proto3" json:"usage,omitempty"`
+	// Why generation stopped: "stop", "length", "safety", "tool_calls" or "other"
+	FinishReason string `protobuf:"bytes,5,opt,name=finish_reason,json=finishReason,proto3" json:"finish_reason,omitempty"`
+	// Model that answered, a fallback model if the requested one failed
+	Model string `protobuf:"bytes,6,opt,name=model,proto3" json:"model,omitempty"`
+	// Exact model version that served the request, as reported by the provider
+	ModelVersion string `protobuf:"bytes,7,opt,name=model_version,json=modelVersion,proto3" json:"model_version,omitempty"`
+	// Functions the model wants called, finish_reason is "tool_calls". Send
+	// them back in an assistant message followed by one tool message each.
+	ToolCalls []*ToolCall `protobuf:"bytes,8,rep,name=tool_calls,json=toolCalls,proto3" json:"tool_calls,omitempty"`
+	// Models that failed before model answered, in the order they were tried
+	Fallbacks     []*Fallback `protobuf:"bytes,9,rep,name=fallbacks,proto3" json:"fallbacks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenAiResponse) Reset() {
+	*x = GenAiResponse{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenAiResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenAiResponse) ProtoMessage() {}
+
+func (x *GenAiResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenAiResponse.ProtoReflect.Descriptor instead.
+func (*GenAiResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GenAiResponse) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *GenAiResponse) GetResponse() string {
+	if x != nil {
+		return x.Response
+	}
+	return ""
+}
+
+func (x *GenAiResponse) GetStatus() *status.Status {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *GenAiResponse) GetUsage() *Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+func (x *GenAiResponse) GetFinishReason() string {
+	if x != nil {
+		return x.FinishReason
+	}
+	return ""
+}
+
+func (x *GenAiResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *GenAiResponse) GetModelVersion() string {
+	if x != nil {
+		return x.ModelVersion
+	}
+	return ""
+}
+
+func (x *GenAiResponse) GetToolCalls() []*ToolCall {
+	if x != nil {
+		return x.ToolCalls
+	}
+	return nil
+}
+
+func (x *GenAiResponse) GetFallbacks() []*Fallback {
+	if x != nil {
+		return x.Fallbacks
+	}
+	return nil
+}
+
+type Fallback struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Model that failed
+	Model string `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	// Why it failed, e.g the provider's error
+	Reason        string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Fallback) Reset() {
+	*x = Fallback{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fallback) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fallback) ProtoMessage() {}
+
+func (x *Fallback) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fallback.ProtoReflect.Descriptor instead.
+func (*Fallback) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Fallback) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Fallback) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type Usage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Tokens in the prompt, including history and system instruction
+	PromptTokens int32 `protobuf:"varint,1,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	// Tokens generated, including any reasoning tokens
+	CompletionTokens int32 `protobuf:"varint,2,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	// Total tokens consumed by the request
+	TotalTokens   int32 `protobuf:"varint,3,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Usage) Reset() {
+	*x = Usage{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Usage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Usage) ProtoMessage() {}
+
+func (x *Usage) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Usage.ProtoReflect.Descriptor instead.
+func (*Usage) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Usage) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetCompletionTokens() int32 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *Usage) GetTotalTokens() int32 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+type GenAiStreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Chunk of AI generated text produced since the previous message
+	Delta string `protobuf:"bytes,1,opt,name=delta,proto3" json:"delta,omitempty"`
+	// Set on the final message once generation has finished
+	Done bool `protobuf:"varint,2,opt,name=done,proto3" json:"done,omitempty"`
+	// Token usage reported by the provider
+	Usage *Usage `protobuf:"bytes,3,opt,name=usage,proto3" json:"usage,omitempty"`
+	// Why generation stopped: "stop", "length", "safety", "tool_calls" or "other"
+	FinishReason string `protobuf:"bytes,4,opt,name=finish_reason,json=finishReason,proto3" json:"finish_reason,omitempty"`
+	// Model that answered, a fallback model if the requested one failed
+	Model string `protobuf:"bytes,5,opt,name=model,proto3" json:"model,omitempty"`
+	// Exact model version that served the request, as reported by the provider
+	ModelVersion string `protobuf:"bytes,6,opt,name=model_version,json=modelVersion,proto3" json:"model_version,omitempty"`
+	// Functions the model wants called
+	ToolCalls []*ToolCall `protobuf:"bytes,7,rep,name=tool_calls,json=toolCalls,proto3" json:"tool_calls,omitempty"`
+	// Models that failed before model answered, in the order they were tried
+	Fallbacks     []*Fallback `protobuf:"bytes,8,rep,name=fallbacks,proto3" json:"fallbacks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenAiStreamResponse) Reset() {
+	*x = GenAiStreamResponse{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenAiStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenAiStreamResponse) ProtoMessage() {}
+
+func (x *GenAiStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenAiStreamResponse.ProtoReflect.Descriptor instead.
+func (*GenAiStreamResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GenAiStreamResponse) GetDelta() string {
+	if x != nil {
+		return x.Delta
+	}
+	return ""
+}
+
+func (x *GenAiStreamResponse) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *GenAiStreamResponse) GetUsage() *Usage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+func (x *GenAiStreamResponse) GetFinishReason() string {
+	if x != nil {
+		return x.FinishReason
+	}
+	return ""
+}
+
+func (x *GenAiStreamResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *GenAiStreamResponse) GetModelVersion() string {
+	if x != nil {
+		return x.ModelVersion
+	}
+	return ""
+}
+
+func (x *GenAiStreamResponse) GetToolCalls() []*ToolCall {
+	if x != nil {
+		return x.ToolCalls
+	}
+	return nil
+}
+
+func (x *GenAiStreamResponse) GetFallbacks() []*Fallback {
+	if x != nil {
+		return x.Fallbacks
+	}
+	return nil
+}
+
+type CountTokensResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Input tokens of the request, including history and system instruction
+	TotalTokens int32 `protobuf:"varint,1,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`
+	// Whether total_tokens is a local estimate rather than counted by the
+	// provider
+	Estimated bool `protobuf:"varint,2,opt,name=estimated,proto3" json:"estimated,omitempty"`
+	// Model the request was routed to
+	Model string `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	// Maximum input tokens of the model, 0 when unknown
+	ContextWindow int32 `protobuf:"varint,4,opt,name=context_window,json=contextWindow,proto3" json:"context_window,omitempty"`
+	// Whether total_tokens fits in context_window, unset when it is unknown
+	Fits          *bool `protobuf:"varint,5,opt,name=fits,proto3,oneof" json:"fits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CountTokensResponse) Reset() {
+	*x = CountTokensResponse{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CountTokensResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CountTokensResponse) ProtoMessage() {}
+
+func (x *CountTokensResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CountTokensResponse.ProtoReflect.Descriptor instead.
+func (*CountTokensResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CountTokensResponse) GetTotalTokens() int32 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+func (x *CountTokensResponse) GetEstimated() bool {
+	if x != nil {
+		return x.Estimated
+	}
+	return false
+}
+
+func (x *CountTokensResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *CountTokensResponse) GetContextWindow() int32 {
+	if x != nil {
+		return x.ContextWindow
+	}
+	return 0
+}
+
+func (x *CountTokensResponse) GetFits() bool {
+	if x != nil && x.Fits != nil {
+		return *x.Fits
+	}
+	return false
+}
+
+type EmbedRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Texts to embed, at most 2048
+	Inputs []string `protobuf:"bytes,1,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	// Embedding model, e.g text-embedding-3-small or gemini-embedding-001.
+	// Must be one of the models allowed in the config file.
+	Model string `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	// Truncate the vectors to this many dimensions, where the model supports it
+	Dimensions *int32 `protobuf:"varint,3,opt,name=dimensions,proto3,oneof" json:"dimensions,omitempty"`
+	// Intended use of the vectors, e.g RETRIEVAL_QUERY, RETRIEVAL_DOCUMENT or
+	// SEMANTIC_SIMILARITY. Only Gemini models use it.
+	TaskType      string `protobuf:"bytes,4,opt,name=task_type,json=taskType,proto3" json:"task_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GenAiRequest) Reset() {
-	*x = GenAiRequest{}
-	mi := &file_api_v1_gemini_service_proto_msgTypes[0]
+func (x *EmbedRequest) Reset() {
+	*x = EmbedRequest{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GenAiRequest) String() string {
+func (x *EmbedRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GenAiRequest) ProtoMessage() {}
+func (*EmbedRequest) ProtoMessage() {}
 
-func (x *GenAiRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1_gemini_service_proto_msgTypes[0]
+func (x *EmbedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -56,45 +1048,66 @@ func (x *GenAiRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GenAiRequest.ProtoReflect.Descriptor instead.
-func (*GenAiRequest) Descriptor() ([]byte, []int) {
-	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use EmbedRequest.ProtoReflect.Descriptor instead.
+func (*EmbedRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *GenAiRequest) GetPrompt() string {
+func (x *EmbedRequest) GetInputs() []string {
 	if x != nil {
-		return x.Prompt
+		return x.Inputs
+	}
+	return nil
+}
+
+func (x *EmbedRequest) GetModel() string {
+	if x != nil {
+		return x.Model
 	}
 	return ""
 }
 
-type GenAiResponse struct {
+func (x *EmbedRequest) GetDimensions() int32 {
+	if x != nil && x.Dimensions != nil {
+		return *x.Dimensions
+	}
+	return 0
+}
+
+func (x *EmbedRequest) GetTaskType() string {
+	if x != nil {
+		return x.TaskType
+	}
+	return ""
+}
+
+type EmbedResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Original user prompt
-	Prompt string `protobuf:"bytes,1,opt,name=prompt,proto3" json:"prompt,omitempty"`
-	// AI generated response
-	Response string `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
-	// Optional: structured error info
-	Status        *status.Status `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// One embedding per input, in input order
+	Embeddings []*Embedding `protobuf:"bytes,1,rep,name=embeddings,proto3" json:"embeddings,omitempty"`
+	// Model the request was routed to
+	Model string `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	// Token usage reported by the provider, where it reports any
+	Usage         *Usage `protobuf:"bytes,3,opt,name=usage,proto3" json:"usage,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GenAiResponse) Reset() {
-	*x = GenAiResponse{}
-	mi := &file_api_v1_gemini_service_proto_msgTypes[1]
+func (x *EmbedResponse) Reset() {
+	*x = EmbedResponse{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GenAiResponse) String() string {
+func (x *EmbedResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GenAiResponse) ProtoMessage() {}
+func (*EmbedResponse) ProtoMessage() {}
 
-func (x *GenAiResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_v1_gemini_service_proto_msgTypes[1]
+func (x *EmbedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -105,45 +1118,451 @@ func (x *GenAiResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GenAiResponse.ProtoReflect.Descriptor instead.
-func (*GenAiResponse) Descriptor() ([]byte, []int) {
-	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use EmbedResponse.ProtoReflect.Descriptor instead.
+func (*EmbedResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *GenAiResponse) GetPrompt() string {
+func (x *EmbedResponse) GetEmbeddings() []*Embedding {
 	if x != nil {
-		return x.Prompt
+		return x.Embeddings
+	}
+	return nil
+}
+
+func (x *EmbedResponse) GetModel() string {
+	if x != nil {
+		return x.Model
 	}
 	return ""
 }
 
-func (x *GenAiResponse) GetResponse() string {
+func (x *EmbedResponse) GetUsage() *Usage {
 	if x != nil {
-		return x.Response
+		return x.Usage
+	}
+	return nil
+}
+
+type Embedding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []float32              `protobuf:"fixed32,1,rep,packed,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Embedding) Reset() {
+	*x = Embedding{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Embedding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Embedding) ProtoMessage() {}
+
+func (x *Embedding) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Embedding.ProtoReflect.Descriptor instead.
+func (*Embedding) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Embedding) GetValues() []float32 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type ListModelsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Also query each provider for the models it currently serves
+	Live          bool `protobuf:"varint,1,opt,name=live,proto3" json:"live,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListModelsRequest) Reset() {
+	*x = ListModelsRequest{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListModelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListModelsRequest) ProtoMessage() {}
+
+func (x *ListModelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListModelsRequest.ProtoReflect.Descriptor instead.
+func (*ListModelsRequest) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListModelsRequest) GetLive() bool {
+	if x != nil {
+		return x.Live
+	}
+	return false
+}
+
+type ListModelsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Configured models first, then live-listed models, each model once
+	Models        []*ModelInfo `protobuf:"bytes,1,rep,name=models,proto3" json:"models,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListModelsResponse) Reset() {
+	*x = ListModelsResponse{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListModelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListModelsResponse) ProtoMessage() {}
+
+func (x *ListModelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListModelsResponse.ProtoReflect.Descriptor instead.
+func (*ListModelsResponse) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListModelsResponse) GetModels() []*ModelInfo {
+	if x != nil {
+		return x.Models
+	}
+	return nil
+}
+
+type ModelInfo struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Model name to send in requests, e.g gemini-2.5-flash
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Provider serving the model, e.g "gemini" or "openai"
+	Provider string `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`
+	// Human readable name, where the provider reports one
+	DisplayName string `protobuf:"bytes,3,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	// Maximum input tokens, 0 when unknown
+	ContextWindow int32 `protobuf:"varint,4,opt,name=context_window,json=contextWindow,proto3" json:"context_window,omitempty"`
+	// Features supported by the model
+	Capabilities *ModelCapabilities `protobuf:"bytes,5,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	// Whether clients may request the model. Live-listed models must be
+	// added to the config file before they can be used.
+	Allowed       bool `protobuf:"varint,6,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelInfo) Reset() {
+	*x = ModelInfo{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelInfo) ProtoMessage() {}
+
+func (x *ModelInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelInfo.ProtoReflect.Descriptor instead.
+func (*ModelInfo) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ModelInfo) GetId() string {
+	if x != nil {
+		return x.Id
 	}
 	return ""
 }
 
-func (x *GenAiResponse) GetStatus() *status.Status {
+func (x *ModelInfo) GetProvider() string {
 	if x != nil {
-		return x.Status
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *ModelInfo) GetContextWindow() int32 {
+	if x != nil {
+		return x.ContextWindow
+	}
+	return 0
+}
+
+func (x *ModelInfo) GetCapabilities() *ModelCapabilities {
+	if x != nil {
+		return x.Capabilities
 	}
 	return nil
 }
 
+func (x *ModelInfo) GetAllowed() bool {
+	if x != nil {
+		return x.Allowed
+	}
+	return false
+}
+
+type ModelCapabilities struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Streaming     bool                   `protobuf:"varint,1,opt,name=streaming,proto3" json:"streaming,omitempty"`
+	Vision        bool                   `protobuf:"varint,2,opt,name=vision,proto3" json:"vision,omitempty"`
+	Tools         bool                   `protobuf:"varint,3,opt,name=tools,proto3" json:"tools,omitempty"`
+	JsonMode      bool                   `protobuf:"varint,4,opt,name=json_mode,json=jsonMode,proto3" json:"json_mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModelCapabilities) Reset() {
+	*x = ModelCapabilities{}
+	mi := &file_api_v1_gemini_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModelCapabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModelCapabilities) ProtoMessage() {}
+
+func (x *ModelCapabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_api_v1_gemini_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModelCapabilities.ProtoReflect.Descriptor instead.
+func (*ModelCapabilities) Descriptor() ([]byte, []int) {
+	return file_api_v1_gemini_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ModelCapabilities) GetStreaming() bool {
+	if x != nil {
+		return x.Streaming
+	}
+	return false
+}
+
+func (x *ModelCapabilities) GetVision() bool {
+	if x != nil {
+		return x.Vision
+	}
+	return false
+}
+
+func (x *ModelCapabilities) GetTools() bool {
+	if x != nil {
+		return x.Tools
+	}
+	return false
+}
+
+func (x *ModelCapabilities) GetJsonMode() bool {
+	if x != nil {
+		return x.JsonMode
+	}
+	return false
+}
+
 var File_api_v1_gemini_service_proto protoreflect.FileDescriptor
 
 const file_api_v1_gemini_service_proto_rawDesc = "" +
 	"\n" +
-	"\x1bapi/v1/gemini_service.proto\x12\x0fwekalist.api.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/api/field_behavior.proto\x1a\x17google/rpc/status.proto\"+\n" +
+	"\x1bapi/v1/gemini_service.proto\x12\x0fwekalist.api.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/api/field_behavior.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x17google/rpc/status.proto\"\xbd\x03\n" +
 	"\fGenAiRequest\x12\x1b\n" +
-	"\x06prompt\x18\x01 \x01(\tB\x03\xe0A\x02R\x06prompt\"~\n" +
+	"\x06prompt\x18\x01 \x01(\tB\x03\xe0A\x01R\x06prompt\x129\n" +
+	"\bmessages\x18\x02 \x03(\v2\x18.wekalist.api.v1.MessageB\x03\xe0A\x01R\bmessages\x12\x19\n" +
+	"\x05model\x18\x03 \x01(\tB\x03\xe0A\x01R\x05model\x12S\n" +
+	"\x11generation_config\x18\x04 \x01(\v2!.wekalist.api.v1.GenerationConfigB\x03\xe0A\x01R\x10generationConfig\x122\n" +
+	"\x12system_instruction\x18\x05 \x01(\tB\x03\xe0A\x01R\x11systemInstruction\x120\n" +
+	"\x05parts\x18\x06 \x03(\v2\x15.wekalist.api.v1.PartB\x03\xe0A\x01R\x05parts\x120\n" +
+	"\x05tools\x18\a \x03(\v2\x15.wekalist.api.v1.ToolB\x03\xe0A\x01R\x05tools\x12M\n" +
+	"\x0fresponse_format\x18\b \x01(\v2\x1f.wekalist.api.v1.ResponseFormatB\x03\xe0A\x01R\x0eresponseFormat\"x\n" +
+	"\x0eResponseFormat\x12\x17\n" +
+	"\x04type\x18\x01 \x01(\tB\x03\xe0A\x01R\x04type\x12\x17\n" +
+	"\x04name\x18\x02 \x01(\tB\x03\xe0A\x01R\x04name\x124\n" +
+	"\x06schema\x18\x03 \x01(\v2\x17.google.protobuf.StructB\x03\xe0A\x01R\x06schema\"\x84\x01\n" +
+	"\x04Tool\x12\x17\n" +
+	"\x04name\x18\x01 \x01(\tB\x03\xe0A\x02R\x04name\x12%\n" +
+	"\vdescription\x18\x02 \x01(\tB\x03\xe0A\x01R\vdescription\x12<\n" +
+	"\n" +
+	"parameters\x18\x03 \x01(\v2\x17.google.protobuf.StructB\x03\xe0A\x01R\n" +
+	"parameters\"e\n" +
+	"\bToolCall\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x125\n" +
+	"\targuments\x18\x03 \x01(\v2\x17.google.protobuf.StructR\targuments\"\xfd\x01\n" +
+	"\x10GenerationConfig\x12%\n" +
+	"\vtemperature\x18\x01 \x01(\x02H\x00R\vtemperature\x88\x01\x01\x12\x18\n" +
+	"\x05top_p\x18\x02 \x01(\x02H\x01R\x04topP\x88\x01\x01\x12/\n" +
+	"\x11max_output_tokens\x18\x03 \x01(\x05H\x02R\x0fmaxOutputTokens\x88\x01\x01\x12%\n" +
+	"\x0estop_sequences\x18\x04 \x03(\tR\rstopSequences\x12\x17\n" +
+	"\x04seed\x18\x05 \x01(\x03H\x03R\x04seed\x88\x01\x01B\x0e\n" +
+	"\f_temperatureB\b\n" +
+	"\x06_top_pB\x14\n" +
+	"\x12_max_output_tokensB\a\n" +
+	"\x05_seed\"\xd9\x01\n" +
+	"\aMessage\x12\x17\n" +
+	"\x04role\x18\x01 \x01(\tB\x03\xe0A\x02R\x04role\x12\x1d\n" +
+	"\acontent\x18\x02 \x01(\tB\x03\xe0A\x01R\acontent\x120\n" +
+	"\x05parts\x18\x03 \x03(\v2\x15.wekalist.api.v1.PartB\x03\xe0A\x01R\x05parts\x12=\n" +
+	"\n" +
+	"tool_calls\x18\x04 \x03(\v2\x19.wekalist.api.v1.ToolCallB\x03\xe0A\x01R\ttoolCalls\x12%\n" +
+	"\ftool_call_id\x18\x05 \x01(\tB\x03\xe0A\x01R\n" +
+	"toolCallId\"\\\n" +
+	"\x04Part\x12 \n" +
+	"\tmime_type\x18\x01 \x01(\tB\x03\xe0A\x02R\bmimeType\x12\x14\n" +
+	"\x04data\x18\x02 \x01(\fH\x00R\x04data\x12\x12\n" +
+	"\x03url\x18\x03 \x01(\tH\x00R\x03urlB\b\n" +
+	"\x06source\"\x9d\x03\n" +
 	"\rGenAiResponse\x12\x1b\n" +
 	"\x06prompt\x18\x01 \x01(\tB\x03\xe0A\x02R\x06prompt\x12\x1f\n" +
 	"\bresponse\x18\x02 \x01(\tB\x03\xe0A\x02R\bresponse\x12/\n" +
-	"\x06status\x18\x03 \x01(\v2\x12.google.rpc.StatusB\x03\xe0A\x01R\x06status2n\n" +
-	"\tAiService\x12a\n" +
-	"\x05GenAi\x12\x1d.wekalist.api.v1.GenAiRequest\x1a\x1e.wekalist.api.v1.GenAiResponse\"\x19\x82\xd3\xe4\x93\x02\x13:\x06prompt\"\t/v1/genaiB\fZ\n" +
+	"\x06status\x18\x03 \x01(\v2\x12.google.rpc.StatusB\x03\xe0A\x01R\x06status\x121\n" +
+	"\x05usage\x18\x04 \x01(\v2\x16.wekalist.api.v1.UsageB\x03\xe0A\x01R\x05usage\x12(\n" +
+	"\rfinish_reason\x18\x05 \x01(\tB\x03\xe0A\x01R\ffinishReason\x12\x19\n" +
+	"\x05model\x18\x06 \x01(\tB\x03\xe0A\x01R\x05model\x12(\n" +
+	"\rmodel_version\x18\a \x01(\tB\x03\xe0A\x01R\fmodelVersion\x12=\n" +
+	"\n" +
+	"tool_calls\x18\b \x03(\v2\x19.wekalist.api.v1.ToolCallB\x03\xe0A\x01R\ttoolCalls\x12<\n" +
+	"\tfallbacks\x18\t \x03(\v2\x19.wekalist.api.v1.FallbackB\x03\xe0A\x01R\tfallbacks\"8\n" +
+	"\bFallback\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"|\n" +
+	"\x05Usage\x12#\n" +
+	"\rprompt_tokens\x18\x01 \x01(\x05R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x02 \x01(\x05R\x10completionTokens\x12!\n" +
+	"\ftotal_tokens\x18\x03 \x01(\x05R\vtotalTokens\"\xc0\x02\n" +
+	"\x13GenAiStreamResponse\x12\x14\n" +
+	"\x05delta\x18\x01 \x01(\tR\x05delta\x12\x12\n" +
+	"\x04done\x18\x02 \x01(\bR\x04done\x12,\n" +
+	"\x05usage\x18\x03 \x01(\v2\x16.wekalist.api.v1.UsageR\x05usage\x12#\n" +
+	"\rfinish_reason\x18\x04 \x01(\tR\ffinishReason\x12\x14\n" +
+	"\x05model\x18\x05 \x01(\tR\x05model\x12#\n" +
+	"\rmodel_version\x18\x06 \x01(\tR\fmodelVersion\x128\n" +
+	"\n" +
+	"tool_calls\x18\a \x03(\v2\x19.wekalist.api.v1.ToolCallR\ttoolCalls\x127\n" +
+	"\tfallbacks\x18\b \x03(\v2\x19.wekalist.api.v1.FallbackR\tfallbacks\"\xb5\x01\n" +
+	"\x13CountTokensResponse\x12!\n" +
+	"\ftotal_tokens\x18\x01 \x01(\x05R\vtotalTokens\x12\x1c\n" +
+	"\testimated\x18\x02 \x01(\bR\testimated\x12\x14\n" +
+	"\x05model\x18\x03 \x01(\tR\x05model\x12%\n" +
+	"\x0econtext_window\x18\x04 \x01(\x05R\rcontextWindow\x12\x17\n" +
+	"\x04fits\x18\x05 \x01(\bH\x00R\x04fits\x88\x01\x01B\a\n" +
+	"\x05_fits\"\xa1\x01\n" +
+	"\fEmbedRequest\x12\x1b\n" +
+	"\x06inputs\x18\x01 \x03(\tB\x03\xe0A\x02R\x06inputs\x12\x19\n" +
+	"\x05model\x18\x02 \x01(\tB\x03\xe0A\x02R\x05model\x12(\n" +
+	"\n" +
+	"dimensions\x18\x03 \x01(\x05B\x03\xe0A\x01H\x00R\n" +
+	"dimensions\x88\x01\x01\x12 \n" +
+	"\ttask_type\x18\x04 \x01(\tB\x03\xe0A\x01R\btaskTypeB\r\n" +
+	"\v_dimensions\"\x8f\x01\n" +
+	"\rEmbedResponse\x12:\n" +
+	"\n" +
+	"embeddings\x18\x01 \x03(\v2\x1a.wekalist.api.v1.EmbeddingR\n" +
+	"embeddings\x12\x14\n" +
+	"\x05model\x18\x02 \x01(\tR\x05model\x12,\n" +
+	"\x05usage\x18\x03 \x01(\v2\x16.wekalist.api.v1.UsageR\x05usage\"#\n" +
+	"\tEmbedding\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\x02R\x06values\",\n" +
+	"\x11ListModelsRequest\x12\x17\n" +
+	"\x04live\x18\x01 \x01(\bB\x03\xe0A\x01R\x04live\"H\n" +
+	"\x12ListModelsResponse\x122\n" +
+	"\x06models\x18\x01 \x03(\v2\x1a.wekalist.api.v1.ModelInfoR\x06models\"\xe3\x01\n" +
+	"\tModelInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bprovider\x18\x02 \x01(\tR\bprovider\x12!\n" +
+	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x12%\n" +
+	"\x0econtext_window\x18\x04 \x01(\x05R\rcontextWindow\x12F\n" +
+	"\fcapabilities\x18\x05 \x01(\v2\".wekalist.api.v1.ModelCapabilitiesR\fcapabilities\x12\x18\n" +
+	"\aallowed\x18\x06 \x01(\bR\aallowed\"|\n" +
+	"\x11ModelCapabilities\x12\x1c\n" +
+	"\tstreaming\x18\x01 \x01(\bR\tstreaming\x12\x16\n" +
+	"\x06vision\x18\x02 \x01(\bR\x06vision\x12\x14\n" +
+	"\x05tools\x18\x03 \x01(\bR\x05tools\x12\x1b\n" +
+	"\tjson_mode\x18\x04 \x01(\bR\bjsonMode2\xe3\x04\n" +
+	"\tAiService\x12z\n" +
+	"\x05GenAi\x12\x1d.wekalist.api.v1.GenAiRequest\x1a\x1e.wekalist.api.v1.GenAiResponse\"2\x82\xd3\xe4\x93\x02,:\x06promptZ\x17:\x01*\"\x12/v1/genai:generate\"\t/v1/genai\x12\x95\x01\n" +
+	"\vGenAiStream\x12\x1d.wekalist.api.v1.GenAiRequest\x1a$.wekalist.api.v1.GenAiStreamResponse\"?\x82\xd3\xe4\x93\x029:\x06promptZ\x1d:\x01*\"\x18/v1/genai:streamGenerate\"\x10/v1/genai:stream0\x01\x12t\n" +
+	"\vCountTokens\x12\x1d.wekalist.api.v1.GenAiRequest\x1a$.wekalist.api.v1.CountTokensResponse\" \x82\xd3\xe4\x93\x02\x1a:\x01*\"\x15/v1/genai:countTokens\x12a\n" +
+	"\x05Embed\x12\x1d.wekalist.api.v1.EmbedRequest\x1a\x1e.wekalist.api.v1.EmbedResponse\"\x19\x82\xd3\xe4\x93\x02\x13:\x01*\"\x0e/v1/embeddings\x12i\n" +
+	"\n" +
+	"ListModels\x12\".wekalist.api.v1.ListModelsRequest\x1a#.wekalist.api.v1.ListModelsResponse\"\x12\x82\xd3\xe4\x93\x02\f\x12\n" +
+	"/v1/modelsB\fZ\n" +
 	"gen/api/v1b\x06proto3"
 
 var (
@@ -158,21 +1577,67 @@ func file_api_v1_gemini_service_proto_rawDescGZIP() []byte {
 	return file_api_v1_gemini_service_proto_rawDescData
 }
 
-var file_api_v1_gemini_service_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_api_v1_gemini_service_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
 var file_api_v1_gemini_service_proto_goTypes = []any{
-	(*GenAiRequest)(nil),  // 0: wekalist.api.v1.GenAiRequest
-	(*GenAiResponse)(nil), // 1: wekalist.api.v1.GenAiResponse
-	(*status.Status)(nil), // 2: google.rpc.Status
+	(*GenAiRequest)(nil),        // 0: wekalist.api.v1.GenAiRequest
+	(*ResponseFormat)(nil),      // 1: wekalist.api.v1.ResponseFormat
+	(*Tool)(nil),                // 2: wekalist.api.v1.Tool
+	(*ToolCall)(nil),            // 3: wekalist.api.v1.ToolCall
+	(*GenerationConfig)(nil),    // 4: wekalist.api.v1.GenerationConfig
+	(*Message)(nil),             // 5: wekalist.api.v1.Message
+	(*Part)(nil),                // 6: wekalist.api.v1.Part
+	(*GenAiResponse)(nil),       // 7: wekalist.api.v1.GenAiResponse
+	(*Fallback)(nil),            // 8: wekalist.api.v1.Fallback
+	(*Usage)(nil),               // 9: wekalist.api.v1.Usage
+	(*GenAiStreamResponse)(nil), // 10: wekalist.api.v1.GenAiStreamResponse
+	(*CountTokensResponse)(nil), // 11: wekalist.api.v1.CountTokensResponse
+	(*EmbedRequest)(nil),        // 12: wekalist.api.v1.EmbedRequest
+	(*EmbedResponse)(nil),       // 13: wekalist.api.v1.EmbedResponse
+	(*Embedding)(nil),           // 14: wekalist.api.v1.Embedding
+	(*ListModelsRequest)(nil),   // 15: wekalist.api.v1.ListModelsRequest
+	(*ListModelsResponse)(nil),  // 16: wekalist.api.v1.ListModelsResponse
+	(*ModelInfo)(nil),           // 17: wekalist.api.v1.ModelInfo
+	(*ModelCapabilities)(nil),   // 18: wekalist.api.v1.ModelCapabilities
+	(*structpb.Struct)(nil),     // 19: google.protobuf.Struct
+	(*status.Status)(nil),       // 20: google.rpc.Status
 }
 var file_api_v1_gemini_service_proto_depIdxs = []int32{
-	2, // 0: wekalist.api.v1.GenAiResponse.status:type_name -> google.rpc.Status
-	0, // 1: wekalist.api.v1.AiService.GenAi:input_type -> wekalist.api.v1.GenAiRequest
-	1, // 2: wekalist.api.v1.AiService.GenAi:output_type -> wekalist.api.v1.GenAiResponse
-	2, // [2:3] is the sub-list for method output_type
-	1, // [1:2] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	5,  // 0: wekalist.api.v1.GenAiRequest.messages:type_name -> wekalist.api.v1.Message
+	4,  // 1: wekalist.api.v1.GenAiRequest.generation_config:type_name -> wekalist.api.v1.GenerationConfig
+	6,  // 2: wekalist.api.v1.GenAiRequest.parts:type_name -> wekalist.api.v1.Part
+	2,  // 3: wekalist.api.v1.GenAiRequest.tools:type_name -> wekalist.api.v1.Tool
+	1,  // 4: wekalist.api.v1.GenAiRequest.response_format:type_name -> wekalist.api.v1.ResponseFormat
+	19, // 5: wekalist.api.v1.ResponseFormat.schema:type_name -> google.protobuf.Struct
+	19, // 6: wekalist.api.v1.Tool.parameters:type_name -> google.protobuf.Struct
+	19, // 7: wekalist.api.v1.ToolCall.arguments:type_name -> google.protobuf.Struct
+	6,  // 8: wekalist.api.v1.Message.parts:type_name -> wekalist.api.v1.Part
+	3,  // 9: wekalist.api.v1.Message.tool_calls:type_name -> wekalist.api.v1.ToolCall
+	20, // 10: wekalist.api.v1.GenAiResponse.status:type_name -> google.rpc.Status
+	9,  // 11: wekalist.api.v1.GenAiResponse.usage:type_name -> wekalist.api.v1.Usage
+	3,  // 12: wekalist.api.v1.GenAiResponse.tool_calls:type_name -> wekalist.api.v1.ToolCall
+	8,  // 13: wekalist.api.v1.GenAiResponse.fallbacks:type_name -> wekalist.api.v1.Fallback
+	9,  // 14: wekalist.api.v1.GenAiStreamResponse.usage:type_name -> wekalist.api.v1.Usage
+	3,  // 15: wekalist.api.v1.GenAiStreamResponse.tool_calls:type_name -> wekalist.api.v1.ToolCall
+	8,  // 16: wekalist.api.v1.GenAiStreamResponse.fallbacks:type_name -> wekalist.api.v1.Fallback
+	14, // 17: wekalist.api.v1.EmbedResponse.embeddings:type_name -> wekalist.api.v1.Embedding
+	9,  // 18: wekalist.api.v1.EmbedResponse.usage:type_name -> wekalist.api.v1.Usage
+	17, // 19: wekalist.api.v1.ListModelsResponse.models:type_name -> wekalist.api.v1.ModelInfo
+	18, // 20: wekalist.api.v1.ModelInfo.capabilities:type_name -> wekalist.api.v1.ModelCapabilities
+	0,  // 21: wekalist.api.v1.AiService.GenAi:input_type -> wekalist.api.v1.GenAiRequest
+	0,  // 22: wekalist.api.v1.AiService.GenAiStream:input_type -> wekalist.api.v1.GenAiRequest
+	0,  // 23: wekalist.api.v1.AiService.CountTokens:input_type -> wekalist.api.v1.GenAiRequest
+	12, // 24: wekalist.api.v1.AiService.Embed:input_type -> wekalist.api.v1.EmbedRequest
+	15, // 25: wekalist.api.v1.AiService.ListModels:input_type -> wekalist.api.v1.ListModelsRequest
+	7,  // 26: wekalist.api.v1.AiService.GenAi:output_type -> wekalist.api.v1.GenAiResponse
+	10, // 27: wekalist.api.v1.AiService.GenAiStream:output_type -> wekalist.api.v1.GenAiStreamResponse
+	11, // 28: wekalist.api.v1.AiService.CountTokens:output_type -> wekalist.api.v1.CountTokensResponse
+	13, // 29: wekalist.api.v1.AiService.Embed:output_type -> wekalist.api.v1.EmbedResponse
+	16, // 30: wekalist.api.v1.AiService.ListModels:output_type -> wekalist.api.v1.ListModelsResponse
+	26, // [26:31] is the sub-list for method output_type
+	21, // [21:26] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_api_v1_gemini_service_proto_init() }
@@ -180,13 +1645,20 @@ func file_api_v1_gemini_service_proto_init() {
 	if File_api_v1_gemini_service_proto != nil {
 		return
 	}
+	file_api_v1_gemini_service_proto_msgTypes[4].OneofWrappers = []any{}
+	file_api_v1_gemini_service_proto_msgTypes[6].OneofWrappers = []any{
+		(*Part_Data)(nil),
+		(*Part_Url)(nil),
+	}
+	file_api_v1_gemini_service_proto_msgTypes[11].OneofWrappers = []any{}
+	file_api_v1_gemini_service_proto_msgTypes[12].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_v1_gemini_service_proto_rawDesc), len(file_api_v1_gemini_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   19,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
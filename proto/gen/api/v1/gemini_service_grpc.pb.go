@@ -19,14 +19,32 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	AiService_GenAi_FullMethodName = "/wekalist.api.v1.AiService/GenAi"
+	AiService_GenAi_FullMethodName       = "/wekalist.api.v1.AiService/GenAi"
+	AiService_GenAiStream_FullMethodName = "/wekalist.api.v1.AiService/GenAiStream"
+	AiService_CountTokens_FullMethodName = "/wekalist.api.v1.AiService/CountTokens"
+	AiService_Embed_FullMethodName       = "/wekalist.api.v1.AiService/Embed"
+	AiService_ListModels_FullMethodName  = "/wekalist.api.v1.AiService/ListModels"
 )
 
 // AiServiceClient is the client API for AiService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type AiServiceClient interface {
+	// POST /v1/genai takes the bare prompt as its body, the original REST
+	// contract. The full request, with messages, model and generation
+	// settings, is posted to /v1/genai:generate.
 	GenAi(ctx context.Context, in *GenAiRequest, opts ...grpc.CallOption) (*GenAiResponse, error)
+	// Streams the AI generated response back as it is produced.
+	// The REST gateway serves it as text/event-stream.
+	GenAiStream(ctx context.Context, in *GenAiRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GenAiStreamResponse], error)
+	// Counts the input tokens of a GenAi request, without generating, and
+	// reports whether they fit in the model's context window.
+	CountTokens(ctx context.Context, in *GenAiRequest, opts ...grpc.CallOption) (*CountTokensResponse, error)
+	// Embeds a batch of texts as float vectors, in input order.
+	Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error)
+	// Lists the models clients can request, optionally together with the
+	// live model listings of the providers.
+	ListModels(ctx context.Context, in *ListModelsRequest, opts ...grpc.CallOption) (*ListModelsResponse, error)
 }
 
 type aiServiceClient struct {
@@ -47,11 +65,74 @@ func (c *aiServiceClient) GenAi(ctx context.Context, in *GenAiRequest, opts ...g
 	return out, nil
 }
 
+func (c *aiServiceClient) GenAiStream(ctx context.Context, in *GenAiRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GenAiStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AiService_ServiceDesc.Streams[0], AiService_GenAiStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GenAiRequest, GenAiStreamResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AiService_GenAiStreamClient = grpc.ServerStreamingClient[GenAiStreamResponse]
+
+func (c *aiServiceClient) CountTokens(ctx context.Context, in *GenAiRequest, opts ...grpc.CallOption) (*CountTokensResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountTokensResponse)
+	err := c.cc.Invoke(ctx, AiService_CountTokens_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aiServiceClient) Embed(ctx context.Context, in *EmbedRequest, opts ...grpc.CallOption) (*EmbedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmbedResponse)
+	err := c.cc.Invoke(ctx, AiService_Embed_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aiServiceClient) ListModels(ctx context.Context, in *ListModelsRequest, opts ...grpc.CallOption) (*ListModelsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListModelsResponse)
+	err := c.cc.Invoke(ctx, AiService_ListModels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AiServiceServer is the server API for AiService service.
 // All implementations must embed UnimplementedAiServiceServer
 // for forward compatibility.
 type AiServiceServer interface {
+	// POST /v1/genai takes the bare prompt as its body, the original REST
+	// contract. The full request, with messages, model and generation
+	// settings, is posted to /v1/genai:generate.
 	GenAi(context.Context, *GenAiRequest) (*GenAiResponse, error)
+	// Streams the AI generated response back as it is produced.
+	// The REST gateway serves it as text/event-stream.
+	GenAiStream(*GenAiRequest, grpc.ServerStreamingServer[GenAiStreamResponse]) error
+	// Counts the input tokens of a GenAi request, without generating, and
+	// reports whether they fit in the model's context window.
+	CountTokens(context.Context, *GenAiRequest) (*CountTokensResponse, error)
+	// Embeds a batch of texts as float vectors, in input order.
+	Embed(context.Context, *EmbedRequest) (*EmbedResponse, error)
+	// Lists the models clients can request, optionally together with the
+	// live model listings of the providers.
+	ListModels(context.Context, *ListModelsRequest) (*ListModelsResponse, error)
 	mustEmbedUnimplementedAiServiceServer()
 }
 
@@ -65,6 +146,18 @@ type UnimplementedAiServiceServer struct{}
 func (UnimplementedAiServiceServer) GenAi(context.Context, *GenAiRequest) (*GenAiResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GenAi not implemented")
 }
+func (UnimplementedAiServiceServer) GenAiStream(*GenAiRequest, grpc.ServerStreamingServer[GenAiStreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method GenAiStream not implemented")
+}
+func (UnimplementedAiServiceServer) CountTokens(context.Context, *GenAiRequest) (*CountTokensResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountTokens not implemented")
+}
+func (UnimplementedAiServiceServer) Embed(context.Context, *EmbedRequest) (*EmbedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Embed not implemented")
+}
+func (UnimplementedAiServiceServer) ListModels(context.Context, *ListModelsRequest) (*ListModelsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListModels not implemented")
+}
 func (UnimplementedAiServiceServer) mustEmbedUnimplementedAiServiceServer() {}
 func (UnimplementedAiServiceServer) testEmbeddedByValue()                   {}
 
@@ -104,6 +197,71 @@ func _AiService_GenAi_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AiService_GenAiStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GenAiRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AiServiceServer).GenAiStream(m, &grpc.GenericServerStream[GenAiRequest, GenAiStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AiService_GenAiStreamServer = grpc.ServerStreamingServer[GenAiStreamResponse]
+
+func _AiService_CountTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenAiRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AiServiceServer).CountTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AiService_CountTokens_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AiServiceServer).CountTokens(ctx, req.(*GenAiRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AiService_Embed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmbedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AiServiceServer).Embed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AiService_Embed_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AiServiceServer).Embed(ctx, req.(*EmbedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AiService_ListModels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListModelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AiServiceServer).ListModels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AiService_ListModels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AiServiceServer).ListModels(ctx, req.(*ListModelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AiService_ServiceDesc is the grpc.ServiceDesc for AiService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,7 +273,25 @@ var AiService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GenAi",
 			Handler:    _AiService_GenAi_Handler,
 		},
+		{
+			MethodName: "CountTokens",
+			Handler:    _AiService_CountTokens_Handler,
+		},
+		{
+			MethodName: "Embed",
+			Handler:    _AiService_Embed_Handler,
+		},
+		{
+			MethodName: "ListModels",
+			Handler:    _AiService_ListModels_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GenAiStream",
+			Handler:       _AiService_GenAiStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/v1/gemini_service.proto",
 }
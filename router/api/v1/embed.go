@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/imrany/wrapper/pkg/provider"
+	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxEmbedInputs bounds the batch size of a single Embed request.
+const maxEmbedInputs = 2048
+
+// maxEmbedDimensions is the largest vector size of the embedding models
+// served, e.g 3072 for text-embedding-3-large and 4096 for some local ones.
+const maxEmbedDimensions = 4096
+
+// MaxEmbedResponseBytes bounds the encoded size of an EmbedResponse: four
+// bytes per float for a full batch of the largest vectors, plus room for
+// the per-vector framing and usage.
+const MaxEmbedResponseBytes = maxEmbedInputs*maxEmbedDimensions*4 + 1<<20
+
+func (s *APIV1Service) Embed(ctx context.Context, req *v1pb.EmbedRequest) (*v1pb.EmbedResponse, error) {
+	if len(req.Inputs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "inputs must be provided")
+	}
+	if len(req.Inputs) > maxEmbedInputs {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d inputs are allowed, got %d", maxEmbedInputs, len(req.Inputs))
+	}
+	for i, input := range req.Inputs {
+		if input == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "inputs[%d]: cannot be empty", i)
+		}
+	}
+	if req.Dimensions != nil && (*req.Dimensions <= 0 || *req.Dimensions > maxEmbedDimensions) {
+		return nil, status.Errorf(codes.InvalidArgument, "dimensions must be between 1 and %d", maxEmbedDimensions)
+	}
+	// The default model is a generative model, so embedding models are
+	// always named explicitly.
+	if req.Model == "" {
+		return nil, status.Error(codes.InvalidArgument, "model must be provided")
+	}
+
+	model, err := s.requestModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	p, upstream, err := s.resolveProvider(model)
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := p.(provider.Embedder)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "provider %s does not support embeddings", p.Name())
+	}
+
+	request := &provider.EmbedRequest{
+		Model:      upstream,
+		Inputs:     req.Inputs,
+		Dimensions: req.Dimensions,
+		TaskType:   req.TaskType,
+	}
+	var result *provider.EmbedResponse
+	err = s.retry(ctx, p, func(ctx context.Context) error {
+		var err error
+		result, err = embedder.Embed(ctx, request)
+		return err
+	})
+	if err != nil {
+		return nil, s.providerError(ctx, p, "embedding", err)
+	}
+
+	embeddings := make([]*v1pb.Embedding, 0, len(result.Embeddings))
+	for _, values := range result.Embeddings {
+		embeddings = append(embeddings, &v1pb.Embedding{Values: values})
+	}
+	return &v1pb.EmbedResponse{
+		Embeddings: embeddings,
+		Model:      model,
+		Usage:      toUsagePB(result.Usage),
+	}, nil
+}
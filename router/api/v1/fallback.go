@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/imrany/wrapper/pkg/provider"
+	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
+	"google.golang.org/grpc/status"
+)
+
+// withFallbacks calls attempt with model, then with each of its configured
+// fallbacks while the previous one fails with an error that outlasted the
+// retries. It returns the model that answered and why the ones before it
+// were skipped. When the requested model rejects the request, e.g with a
+// status error returned by attempt, another model is not tried. A fallback
+// that rejects it, e.g for a content type or generation option it does not
+// support, is skipped like one that cannot be resolved, as the requested
+// model would have accepted the request.
+func (s *APIV1Service) withFallbacks(ctx context.Context, model, call string, attempt func(model string, p provider.Provider, upstream string) error) (string, []*v1pb.Fallback, error) {
+	chain := append([]string{model}, s.Config.FallbacksFor(model)...)
+
+	var (
+		fallbacks []*v1pb.Fallback
+		failed    provider.Provider
+		failure   error
+	)
+	for i, m := range chain {
+		p, upstream, err := s.resolveProvider(m)
+		if err != nil {
+			if i == 0 {
+				return "", nil, err
+			}
+			s.Logger.Warn("Skipping fallback model", "model", m, "error", err)
+			fallbacks = append(fallbacks, &v1pb.Fallback{Model: m, Reason: status.Convert(err).Message()})
+			continue
+		}
+
+		err = attempt(m, p, upstream)
+		if err == nil {
+			return m, fallbacks, nil
+		}
+		var permanent *permanentError
+		if ctx.Err() != nil || errors.As(err, &permanent) {
+			return "", nil, s.providerError(ctx, p, call, err)
+		}
+		_, rejected := status.FromError(err)
+		transient := !rejected && retryable(p, err)
+		if i == 0 && rejected {
+			return "", nil, err
+		}
+		if i == 0 && !transient {
+			return "", nil, s.providerError(ctx, p, call, err)
+		}
+
+		reason := fmt.Sprintf("%s %s failed: %v", p.Name(), call, err)
+		switch {
+		case rejected:
+			reason = status.Convert(err).Message()
+			s.Logger.Warn("Skipping fallback model", "model", m, "error", err)
+		case transient:
+			failed, failure = p, err
+			if i < len(chain)-1 {
+				s.Logger.Warn("Falling back to the next model", "model", m, "fallback", chain[i+1], "error", err)
+			}
+		default:
+			s.Logger.Warn("Skipping fallback model", "model", m, "error", err)
+		}
+		fallbacks = append(fallbacks, &v1pb.Fallback{Model: m, Reason: reason})
+	}
+	return "", nil, s.providerError(ctx, failed, call, failure)
+}
@@ -0,0 +1,63 @@
+package v1
+
+import (
+	"github.com/imrany/wrapper/pkg/provider"
+	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// generationConfig validates the client's generation parameters, fills the
+// unset ones from the model defaults and clamps them to the model limits.
+func (s *APIV1Service) generationConfig(req *v1pb.GenAiRequest, model string) (provider.GenerationConfig, error) {
+	in := req.GetGenerationConfig()
+	if in == nil {
+		in = &v1pb.GenerationConfig{}
+	}
+	if in.Temperature != nil && in.GetTemperature() < 0 {
+		return provider.GenerationConfig{}, status.Error(codes.InvalidArgument, "temperature cannot be negative")
+	}
+	if in.TopP != nil && (in.GetTopP() < 0 || in.GetTopP() > 1) {
+		return provider.GenerationConfig{}, status.Error(codes.InvalidArgument, "top_p must be between 0 and 1")
+	}
+	if in.MaxOutputTokens != nil && in.GetMaxOutputTokens() <= 0 {
+		return provider.GenerationConfig{}, status.Error(codes.InvalidArgument, "max_output_tokens must be positive")
+	}
+	if in.Seed != nil && !provider.ValidSeed(in.GetSeed()) {
+		return provider.GenerationConfig{}, status.Error(codes.InvalidArgument, "seed must fit in 32 bits")
+	}
+
+	modelConfig, _ := s.Config.Model(model)
+	defaults, limits := modelConfig.Defaults, modelConfig.Limits
+
+	gen := provider.GenerationConfig{
+		Temperature:     firstSet(in.Temperature, defaults.Temperature),
+		TopP:            firstSet(in.TopP, defaults.TopP),
+		MaxOutputTokens: firstSet(in.MaxOutputTokens, defaults.MaxOutputTokens),
+		StopSequences:   in.GetStopSequences(),
+		Seed:            firstSet(in.Seed, defaults.Seed),
+	}
+	if len(gen.StopSequences) == 0 {
+		gen.StopSequences = defaults.StopSequences
+	}
+
+	if limits.Temperature > 0 && gen.Temperature != nil && *gen.Temperature > limits.Temperature {
+		gen.Temperature = &limits.Temperature
+	}
+	if limits.MaxOutputTokens > 0 && (gen.MaxOutputTokens == nil || *gen.MaxOutputTokens > limits.MaxOutputTokens) {
+		gen.MaxOutputTokens = &limits.MaxOutputTokens
+	}
+	if limits.StopSequences > 0 && len(gen.StopSequences) > limits.StopSequences {
+		return provider.GenerationConfig{}, status.Errorf(codes.InvalidArgument, "at most %d stop sequences are allowed", limits.StopSequences)
+	}
+	return gen, nil
+}
+
+func firstSet[T any](values ...*T) *T {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
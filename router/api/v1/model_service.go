@@ -2,18 +2,29 @@ package v1
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 
-	geminiwrapper "github.com/imrany/wrapper/pkg/gemini"
-	openaiwrapper "github.com/imrany/wrapper/pkg/openai"
+	"github.com/imrany/wrapper/pkg/chat"
+	"github.com/imrany/wrapper/pkg/provider"
 	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 func (s *APIV1Service) GenAi(ctx context.Context, req *v1pb.GenAiRequest) (*v1pb.GenAiResponse, error) {
-	if req.Prompt == "" {
-		return nil, status.Error(codes.InvalidArgument, "prompt cannot be empty")
+	messages, err := requestMessages(req)
+	if err != nil {
+		return nil, err
+	}
+	tools, err := requestTools(req)
+	if err != nil {
+		return nil, err
+	}
+	format, err := requestResponseFormat(req)
+	if err != nil {
+		return nil, err
 	}
 
 	if ctx.Err() != nil {
@@ -21,57 +32,240 @@ func (s *APIV1Service) GenAi(ctx context.Context, req *v1pb.GenAiRequest) (*v1pb
 		return nil, status.FromContextError(ctx.Err()).Err()
 	}
 
-	// Extract provider from model name (case-insensitive)
-	modelParts := strings.Split(s.Model, "-")
-	if len(modelParts) == 0 {
-		s.Logger.Error("Invalid model format", "model", s.Model)
-		return nil, status.Errorf(codes.InvalidArgument, "invalid model format: %s", s.Model)
+	model, err := s.requestModel(req.Model)
+	if err != nil {
+		return nil, err
 	}
-	provider := strings.ToLower(modelParts[0])
 
-	switch provider {
-	case "gemini":
-		config := geminiwrapper.GeminiClientConfig{
-			APIKey: s.APIKey,
-			Model:  s.Model,
+	var result *provider.Response
+	model, fallbacks, err := s.withFallbacks(ctx, model, "generation", func(model string, p provider.Provider, upstream string) error {
+		generation, err := s.generationConfig(req, model)
+		if err != nil {
+			return err
 		}
-		result, err := config.GenerateGeminiContent(ctx, req.Prompt)
+		result, err = s.generate(ctx, p, &provider.Request{
+			Model:             upstream,
+			SystemInstruction: s.systemInstruction(req, model, messages),
+			Messages:          messages,
+			Generation:        generation,
+			Tools:             tools,
+			ResponseFormat:    format.providerFormat(),
+		}, format)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	toolCalls, err := toToolCallsPB(result.ToolCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1pb.GenAiResponse{
+		Prompt:       req.Prompt,
+		Response:     result.Text,
+		Usage:        toUsagePB(result.Usage),
+		FinishReason: string(result.FinishReason),
+		Model:        model,
+		ModelVersion: result.ModelVersion,
+		ToolCalls:    toolCalls,
+		Fallbacks:    fallbacks,
+	}, nil
+}
+
+func (s *APIV1Service) GenAiStream(req *v1pb.GenAiRequest, stream v1pb.AiService_GenAiStreamServer) error {
+	messages, err := requestMessages(req)
+	if err != nil {
+		return err
+	}
+	tools, err := requestTools(req)
+	if err != nil {
+		return err
+	}
+	format, err := requestResponseFormat(req)
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	if ctx.Err() != nil {
+		s.Logger.Error("Context error", "error", ctx.Err())
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	model, err := s.requestModel(req.Model)
+	if err != nil {
+		return err
+	}
+
+	// Once text has been sent the stream cannot be retried nor fall back to
+	// another model, as the client would receive it twice.
+	sent := false
+	send := func(delta string) error {
+		sent = true
+		return stream.Send(&v1pb.GenAiStreamResponse{Delta: delta})
+	}
+
+	var (
+		result *provider.Response
+		p      provider.Provider
+	)
+	model, fallbacks, err := s.withFallbacks(ctx, model, "stream", func(model string, answering provider.Provider, upstream string) error {
+		generation, err := s.generationConfig(req, model)
 		if err != nil {
-			s.Logger.Error("Gemini generation failed", "error", err)
-			// Check if it's a context error
-			if ctx.Err() != nil {
-				return nil, status.FromContextError(ctx.Err()).Err()
+			return err
+		}
+		request := &provider.Request{
+			Model:             upstream,
+			SystemInstruction: s.systemInstruction(req, model, messages),
+			Messages:          messages,
+			Generation:        generation,
+			Tools:             tools,
+			ResponseFormat:    format.providerFormat(),
+		}
+		p = answering
+		return s.retry(ctx, p, func(ctx context.Context) error {
+			var err error
+			if result, err = p.Stream(ctx, request, send); err != nil && sent {
+				return &permanentError{err}
 			}
-			// Return the actual error to the client for debugging
-			return nil, status.Errorf(codes.Internal, "Gemini generation failed: %v", err)
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// The text has already been sent, so it is validated but not repaired.
+	if format != nil && len(result.ToolCalls) == 0 {
+		if err := format.validate(result.Text); err != nil {
+			s.Logger.Warn("Streamed response does not match response_format", "provider", p.Name(), "error", err)
+			return status.Errorf(codes.Internal, "%s response does not match response_format: %v", p.Name(), err)
 		}
-		return &v1pb.GenAiResponse{
-			Prompt:   req.Prompt,
-			Response: result,
-		}, nil
-
-	case "gpt", "o1":
-		config := openaiwrapper.OpenAIClient{
-			APIKey: s.APIKey,
-			Model:  s.Model,
+	}
+
+	toolCalls, err := toToolCallsPB(result.ToolCalls)
+	if err != nil {
+		return err
+	}
+
+	return stream.Send(&v1pb.GenAiStreamResponse{
+		Done:         true,
+		ToolCalls:    toolCalls,
+		Usage:        toUsagePB(result.Usage),
+		FinishReason: string(result.FinishReason),
+		Model:        model,
+		ModelVersion: result.ModelVersion,
+		Fallbacks:    fallbacks,
+	})
+}
+
+// requestModel returns the model requested by the client, or the default
+// model when none was requested. Aliases are configured by the operator, so
+// their model is always allowed.
+func (s *APIV1Service) requestModel(name string) (string, error) {
+	if name == "" {
+		name = s.Model
+	}
+	if model, ok := s.Providers.Lookup(name); ok {
+		return model, nil
+	}
+	if strings.EqualFold(name, s.Model) {
+		return s.Model, nil
+	}
+
+	m, ok := s.Config.Model(name)
+	if !ok {
+		s.Logger.Warn("Model not allowed", "model", name)
+		return "", status.Errorf(codes.InvalidArgument, "model %q is not allowed", name)
+	}
+	return m.Name, nil
+}
+
+// systemInstruction returns the request's system instruction, falling back
+// to the configured default unless the conversation carries its own system
+// messages.
+func (s *APIV1Service) systemInstruction(req *v1pb.GenAiRequest, model string, messages []chat.Message) string {
+	if req.SystemInstruction != "" {
+		return req.SystemInstruction
+	}
+	for _, m := range messages {
+		if m.Role == chat.RoleSystem {
+			return ""
 		}
-		result, err := config.GenerateOpenAIContent(ctx, req.Prompt)
+	}
+	return s.Config.SystemPromptFor(model)
+}
+
+// resolveProvider looks up the provider registered for model and the model
+// name to send to it.
+func (s *APIV1Service) resolveProvider(model string) (provider.Provider, string, error) {
+	p, upstream, err := s.Providers.Resolve(model)
+	if errors.Is(err, provider.ErrUnsupportedModel) {
+		s.Logger.Warn("Unsupported model", "model", model)
+		return nil, "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, "", status.Error(codes.Internal, err.Error())
+	}
+	return p, upstream, nil
+}
+
+func toUsagePB(u provider.Usage) *v1pb.Usage {
+	return &v1pb.Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+// requestMessages builds the conversation from the request history, with the
+// prompt and its parts (if any) appended as the final user turn. Tool
+// messages must answer a tool call of an earlier assistant message.
+func requestMessages(req *v1pb.GenAiRequest) ([]chat.Message, error) {
+	var parts partsValidator
+	calls := make(map[string]bool)
+	messages := make([]chat.Message, 0, len(req.Messages)+1)
+	for i, m := range req.Messages {
+		role, err := chat.ParseRole(m.Role)
 		if err != nil {
-			s.Logger.Error("OpenAI generation failed", "error", err)
-			// Check if it's a context error
-			if ctx.Err() != nil {
-				return nil, status.FromContextError(ctx.Err()).Err()
-			}
-			// Return the actual error to the client for debugging
-			return nil, status.Errorf(codes.Internal, "OpenAI generation failed: %v", err)
+			return nil, status.Errorf(codes.InvalidArgument, "messages[%d]: %v", i, err)
+		}
+		switch {
+		case len(m.ToolCalls) > 0 && role != chat.RoleAssistant:
+			return nil, status.Errorf(codes.InvalidArgument, "messages[%d]: only assistant messages can have tool_calls", i)
+		case m.ToolCallId != "" && role != chat.RoleTool:
+			return nil, status.Errorf(codes.InvalidArgument, "messages[%d]: only tool messages can have a tool_call_id", i)
+		case role == chat.RoleTool && !calls[m.ToolCallId]:
+			return nil, status.Errorf(codes.InvalidArgument, "messages[%d]: tool_call_id %q does not match an earlier tool call", i, m.ToolCallId)
+		case (role == chat.RoleSystem || role == chat.RoleTool) && len(m.Parts) > 0:
+			return nil, status.Errorf(codes.InvalidArgument, "messages[%d]: %s messages cannot have parts", i, role)
+		case role != chat.RoleTool && m.Content == "" && len(m.Parts) == 0 && len(m.ToolCalls) == 0:
+			return nil, status.Errorf(codes.InvalidArgument, "messages[%d]: content or parts must be provided", i)
 		}
-		return &v1pb.GenAiResponse{
-			Prompt:   req.Prompt,
-			Response: result,
-		}, nil
 
-	default:
-		s.Logger.Warn("Unsupported model", "model", s.Model)
-		return nil, status.Errorf(codes.InvalidArgument, "unsupported model: %s", s.Model)
+		message := chat.Message{Role: role, Content: m.Content, ToolCallID: m.ToolCallId}
+		if message.Parts, err = parts.toParts(fmt.Sprintf("messages[%d].parts", i), m.Parts); err != nil {
+			return nil, err
+		}
+		if message.ToolCalls, err = requestToolCalls(fmt.Sprintf("messages[%d].tool_calls", i), m.ToolCalls, calls); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	if req.Prompt != "" || len(req.Parts) > 0 {
+		message := chat.Message{Role: chat.RoleUser, Content: req.Prompt}
+		var err error
+		if message.Parts, err = parts.toParts("parts", req.Parts); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	if len(messages) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "prompt or messages must be provided")
 	}
+	return messages, nil
 }
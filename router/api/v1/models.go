@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/imrany/wrapper/pkg/provider"
+	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
+)
+
+func (s *APIV1Service) ListModels(ctx context.Context, req *v1pb.ListModelsRequest) (*v1pb.ListModelsResponse, error) {
+	var live map[string]provider.ModelInfo
+	if req.Live {
+		live = s.liveModels(ctx)
+	}
+
+	seen := make(map[string]bool)
+	var models []*v1pb.ModelInfo
+	add := func(m *v1pb.ModelInfo) {
+		key := strings.ToLower(m.Id)
+		if !seen[key] {
+			seen[key] = true
+			models = append(models, m)
+		}
+	}
+
+	for _, name := range s.configuredModels() {
+		p, upstream, err := s.Providers.Resolve(name)
+		if err != nil {
+			continue
+		}
+
+		m := &v1pb.ModelInfo{
+			Id:           name,
+			Provider:     p.Name(),
+			Capabilities: toCapabilitiesPB(p.Capabilities(upstream)),
+			Allowed:      true,
+		}
+		if info, ok := live[strings.ToLower(upstream)]; ok {
+			m.DisplayName = info.DisplayName
+			m.ContextWindow = int32(info.ContextWindow)
+		}
+		if cfg, ok := s.Config.Model(name); ok && cfg.ContextWindow > 0 {
+			m.ContextWindow = int32(cfg.ContextWindow)
+		}
+		add(m)
+	}
+
+	ids := make([]string, 0, len(live))
+	for id := range live {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		info := live[id]
+		p, ok := s.Providers.Get(info.Provider)
+		if !ok {
+			continue
+		}
+		add(&v1pb.ModelInfo{
+			Id:            info.ID,
+			Provider:      info.Provider,
+			DisplayName:   info.DisplayName,
+			ContextWindow: int32(info.ContextWindow),
+			Capabilities:  toCapabilitiesPB(p.Capabilities(info.ID)),
+		})
+	}
+
+	return &v1pb.ListModelsResponse{Models: models}, nil
+}
+
+// configuredModels returns the models clients may request: the default
+// model, the configured models and the aliases.
+func (s *APIV1Service) configuredModels() []string {
+	names := []string{s.Model}
+	names = append(names, s.Config.ModelNames()...)
+
+	var aliases []string
+	for alias := range s.Providers.Aliases() {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return append(names, aliases...)
+}
+
+// liveModels queries every provider concurrently, keyed by lowercase model
+// ID. Providers that fail are logged and left out.
+func (s *APIV1Service) liveModels(ctx context.Context) map[string]provider.ModelInfo {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		models = make(map[string]provider.ModelInfo)
+	)
+	for _, p := range s.Providers.Providers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			list, err := p.ListModels(ctx)
+			if err != nil {
+				s.Logger.Warn("Listing models failed", "provider", p.Name(), "error", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, m := range list {
+				models[strings.ToLower(m.ID)] = m
+			}
+		}()
+	}
+	wg.Wait()
+	return models
+}
+
+func toCapabilitiesPB(c provider.Capabilities) *v1pb.ModelCapabilities {
+	return &v1pb.ModelCapabilities{
+		Streaming: c.Streaming,
+		Vision:    c.Vision,
+		Tools:     c.Tools,
+		JsonMode:  c.JSONMode,
+	}
+}
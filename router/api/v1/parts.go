@@ -0,0 +1,78 @@
+package v1
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/imrany/wrapper/pkg/chat"
+	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxInlineBytes bounds the inline part data of a whole request, matching the
+// Gemini API limit for inline data. The gRPC server must accept messages of
+// at least this size.
+const MaxInlineBytes = 20 << 20
+
+// partMIMETypes are the media types accepted in parts. Providers may support
+// fewer, e.g OpenAI only accepts images.
+var partMIMETypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/webp":      true,
+	"image/gif":       true,
+	"image/heic":      true,
+	"image/heif":      true,
+	"application/pdf": true,
+}
+
+// partSchemes are the URL schemes accepted in parts.
+var partSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+	"gs":    true,
+}
+
+// partsValidator converts the parts of a request, keeping track of the inline
+// bytes left across all its messages.
+type partsValidator struct {
+	inlineBytes int
+}
+
+// toParts validates parts and converts them, field names the parts in error
+// messages, e.g "messages[2].parts".
+func (v *partsValidator) toParts(field string, parts []*v1pb.Part) ([]chat.Part, error) {
+	out := make([]chat.Part, 0, len(parts))
+	for i, part := range parts {
+		if !partMIMETypes[part.MimeType] {
+			return nil, status.Errorf(codes.InvalidArgument, "%s[%d]: unsupported mime_type %q", field, i, part.MimeType)
+		}
+
+		switch source := part.Source.(type) {
+		case *v1pb.Part_Data:
+			if len(source.Data) == 0 {
+				return nil, status.Errorf(codes.InvalidArgument, "%s[%d]: data cannot be empty", field, i)
+			}
+			v.inlineBytes += len(source.Data)
+			if v.inlineBytes > MaxInlineBytes {
+				return nil, status.Errorf(codes.InvalidArgument, "inline data exceeds %d bytes, send large files by url", MaxInlineBytes)
+			}
+			// DetectContentType recognises every accepted type except HEIC
+			// and HEIF, which it reports as application/octet-stream.
+			if detected := http.DetectContentType(source.Data); detected != part.MimeType && detected != "application/octet-stream" {
+				return nil, status.Errorf(codes.InvalidArgument, "%s[%d]: data is %s, not %s", field, i, detected, part.MimeType)
+			}
+			out = append(out, chat.Part{MIMEType: part.MimeType, Data: source.Data})
+		case *v1pb.Part_Url:
+			u, err := url.Parse(source.Url)
+			if err != nil || !partSchemes[u.Scheme] || u.Host == "" {
+				return nil, status.Errorf(codes.InvalidArgument, "%s[%d]: url must be an absolute http, https or gs url", field, i)
+			}
+			out = append(out, chat.Part{MIMEType: part.MimeType, URL: source.Url})
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "%s[%d]: data or url must be provided", field, i)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/imrany/wrapper/pkg/chat"
+	"github.com/imrany/wrapper/pkg/provider"
+	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// responseFormat is a validated response_format with its compiled schema.
+type responseFormat struct {
+	format *provider.ResponseFormat
+	schema *jsonschema.Schema
+}
+
+// requestResponseFormat validates the response_format of req. It returns nil
+// for plain text responses.
+func requestResponseFormat(req *v1pb.GenAiRequest) (*responseFormat, error) {
+	in := req.GetResponseFormat()
+	switch provider.ResponseFormatType(in.GetType()) {
+	case "", "text":
+		if in.GetSchema() != nil {
+			return nil, status.Error(codes.InvalidArgument, `response_format: schema requires type "json_schema"`)
+		}
+		return nil, nil
+	case provider.ResponseJSON:
+		if in.GetSchema() != nil {
+			return nil, status.Error(codes.InvalidArgument, `response_format: schema requires type "json_schema"`)
+		}
+		return &responseFormat{format: &provider.ResponseFormat{Type: provider.ResponseJSON}}, nil
+	case provider.ResponseJSONSchema:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, `response_format: unknown type %q, expected "text", "json_object" or "json_schema"`, in.GetType())
+	}
+
+	if in.Name != "" && !toolName.MatchString(in.Name) {
+		return nil, status.Error(codes.InvalidArgument, "response_format: name must be 1-64 letters, digits, underscores or dashes")
+	}
+	if in.Schema == nil {
+		return nil, status.Error(codes.InvalidArgument, `response_format: schema is required for type "json_schema"`)
+	}
+	doc := in.Schema.AsMap()
+	if typ, ok := doc["type"]; ok && typ != "object" {
+		return nil, status.Error(codes.InvalidArgument, "response_format: schema must be an object schema")
+	}
+
+	// Clients must not make the server read $refs, so the default file
+	// loader is replaced by an empty one that reads nothing from disk or
+	// network.
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := compiler.AddResource("urn:wrapper:response", doc); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "response_format: invalid schema: %v", err)
+	}
+	schema, err := compiler.Compile("urn:wrapper:response")
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "response_format: invalid schema: %v", err)
+	}
+
+	return &responseFormat{
+		format: &provider.ResponseFormat{Type: provider.ResponseJSONSchema, Name: in.Name, Schema: doc},
+		schema: schema,
+	}, nil
+}
+
+// providerFormat returns the format to send to the provider, nil for text.
+func (f *responseFormat) providerFormat() *provider.ResponseFormat {
+	if f == nil {
+		return nil
+	}
+	return f.format
+}
+
+// validate checks that text is a JSON object matching the schema, if any.
+func (f *responseFormat) validate(text string) error {
+	var v any
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	if _, ok := v.(map[string]any); !ok {
+		return errors.New("response is not a JSON object")
+	}
+	if f.schema != nil {
+		return f.schema.Validate(v)
+	}
+	return nil
+}
+
+// generate calls the provider, retrying transient errors, and validates the
+// response against format. Invalid responses are sent back to the model for
+// correction up to the configured number of repair retries. Responses with
+// tool calls carry no JSON and are returned as is.
+func (s *APIV1Service) generate(ctx context.Context, p provider.Provider, req *provider.Request, format *responseFormat) (*provider.Response, error) {
+	var result *provider.Response
+	call := func(req *provider.Request) error {
+		return s.retry(ctx, p, func(ctx context.Context) error {
+			var err error
+			result, err = p.Generate(ctx, req)
+			return err
+		})
+	}
+	if err := call(req); err != nil || format == nil {
+		return result, err
+	}
+
+	usage := result.Usage
+	for attempt := 0; len(result.ToolCalls) == 0; attempt++ {
+		invalid := format.validate(result.Text)
+		if invalid == nil {
+			break
+		}
+		if attempt == s.Config.ResponseFormat.RepairRetries {
+			return nil, fmt.Errorf("response does not match response_format: %w", invalid)
+		}
+		s.Logger.Warn("Repairing invalid JSON response", "provider", p.Name(), "attempt", attempt+1, "error", invalid)
+
+		repair := *req
+		repair.Messages = append(req.Messages[:len(req.Messages):len(req.Messages)],
+			chat.Message{Role: chat.RoleAssistant, Content: result.Text},
+			chat.Message{Role: chat.RoleUser, Content: fmt.Sprintf("Your response is invalid: %v\nReply with the corrected JSON only.", invalid)},
+		)
+		if err := call(&repair); err != nil {
+			return nil, err
+		}
+		usage.PromptTokens += result.Usage.PromptTokens
+		usage.CompletionTokens += result.Usage.CompletionTokens
+		usage.TotalTokens += result.Usage.TotalTokens
+	}
+	result.Usage = usage
+	return result, nil
+}
@@ -0,0 +1,61 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/imrany/wrapper/pkg/provider"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// retry calls fn under the configured retry policy, retrying the errors p
+// classifies as transient.
+func (s *APIV1Service) retry(ctx context.Context, p provider.Provider, fn func(context.Context) error) error {
+	onRetry := func(attempt int, wait time.Duration, err error) {
+		s.Logger.Warn("Retrying provider call", "provider", p.Name(), "attempt", attempt, "wait", wait, "error", err)
+	}
+	classify := func(err error) bool {
+		var permanent *permanentError
+		return !errors.As(err, &permanent) && retryable(p, err)
+	}
+	return s.Config.Retry.Do(ctx, classify, onRetry, fn)
+}
+
+// permanentError marks an error that must not be retried whatever its
+// cause, e.g after part of a stream was sent.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// retryable reports whether err of p is transient.
+func retryable(p provider.Provider, err error) bool {
+	if errors.Is(err, provider.ErrUnsupportedContent) {
+		return false
+	}
+	if c, ok := p.(provider.RetryClassifier); ok {
+		return c.Retryable(err)
+	}
+	return provider.IsTransient(err)
+}
+
+// providerError converts a failed provider call to a status. Transient
+// errors that outlasted the retries are Unavailable, so clients know to
+// try again later.
+func (s *APIV1Service) providerError(ctx context.Context, p provider.Provider, call string, err error) error {
+	s.Logger.Error("Provider call failed", "provider", p.Name(), "call", call, "error", err)
+	switch {
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	case errors.Is(err, provider.ErrUnsupportedContent):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case retryable(p, err):
+		return status.Errorf(codes.Unavailable, "%s %s failed: %v", p.Name(), call, err)
+	default:
+		return status.Errorf(codes.Internal, "%s %s failed: %v", p.Name(), call, err)
+	}
+}
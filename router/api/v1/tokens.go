@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/imrany/wrapper/pkg/provider"
+	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
+)
+
+func (s *APIV1Service) CountTokens(ctx context.Context, req *v1pb.GenAiRequest) (*v1pb.CountTokensResponse, error) {
+	messages, err := requestMessages(req)
+	if err != nil {
+		return nil, err
+	}
+	tools, err := requestTools(req)
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := s.requestModel(req.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	p, upstream, err := s.resolveProvider(model)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &provider.Request{
+		Model:             upstream,
+		SystemInstruction: s.systemInstruction(req, model, messages),
+		Messages:          messages,
+		Tools:             tools,
+	}
+
+	resp := &v1pb.CountTokensResponse{Model: model}
+	if counter, ok := p.(provider.TokenCounter); ok {
+		err = s.retry(ctx, p, func(ctx context.Context) error {
+			var err error
+			resp.TotalTokens, err = counter.CountTokens(ctx, request)
+			return err
+		})
+		if err != nil {
+			return nil, s.providerError(ctx, p, "token count", err)
+		}
+	} else {
+		resp.TotalTokens = provider.EstimateTokens(request)
+		resp.Estimated = true
+	}
+
+	if m, ok := s.Config.Model(model); ok && m.ContextWindow > 0 {
+		resp.ContextWindow = int32(m.ContextWindow)
+		fits := resp.TotalTokens <= resp.ContextWindow
+		resp.Fits = &fits
+	}
+	return resp, nil
+}
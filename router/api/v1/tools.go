@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"regexp"
+
+	"github.com/imrany/wrapper/pkg/chat"
+	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// toolName is the name format every provider accepts.
+var toolName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// requestTools validates and converts the tool declarations of req.
+func requestTools(req *v1pb.GenAiRequest) ([]chat.Tool, error) {
+	tools := make([]chat.Tool, 0, len(req.Tools))
+	seen := make(map[string]bool, len(req.Tools))
+	for i, t := range req.Tools {
+		if !toolName.MatchString(t.Name) {
+			return nil, status.Errorf(codes.InvalidArgument, "tools[%d]: name must be 1-64 letters, digits, underscores or dashes", i)
+		}
+		if seen[t.Name] {
+			return nil, status.Errorf(codes.InvalidArgument, "tools[%d]: duplicate name %q", i, t.Name)
+		}
+		seen[t.Name] = true
+
+		tool := chat.Tool{Name: t.Name, Description: t.Description}
+		if t.Parameters != nil {
+			tool.Parameters = t.Parameters.AsMap()
+			if typ, ok := tool.Parameters["type"]; ok && typ != "object" {
+				return nil, status.Errorf(codes.InvalidArgument, "tools[%d]: parameters must be an object schema", i)
+			}
+		}
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
+
+// requestToolCalls validates and converts the tool calls of an assistant
+// message, recording their IDs in calls.
+func requestToolCalls(field string, toolCalls []*v1pb.ToolCall, calls map[string]bool) ([]chat.ToolCall, error) {
+	out := make([]chat.ToolCall, 0, len(toolCalls))
+	for i, call := range toolCalls {
+		if call.Id == "" || call.Name == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "%s[%d]: id and name are required", field, i)
+		}
+		calls[call.Id] = true
+		out = append(out, chat.ToolCall{ID: call.Id, Name: call.Name, Arguments: call.Arguments.AsMap()})
+	}
+	return out, nil
+}
+
+func toToolCallsPB(calls []chat.ToolCall) ([]*v1pb.ToolCall, error) {
+	out := make([]*v1pb.ToolCall, 0, len(calls))
+	for _, call := range calls {
+		args, err := structpb.NewStruct(call.Arguments)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "tool call %s: %v", call.Name, err)
+		}
+		out = append(out, &v1pb.ToolCall{Id: call.ID, Name: call.Name, Arguments: args})
+	}
+	return out, nil
+}
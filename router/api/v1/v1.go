@@ -3,12 +3,15 @@ package v1
 import (
 	"log/slog"
 
+	"github.com/imrany/wrapper/pkg/config"
+	"github.com/imrany/wrapper/pkg/provider"
 	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
 )
 
 type APIV1Service struct {
 	v1pb.UnimplementedAiServiceServer
-	APIKey string
-	Model  string
-	Logger *slog.Logger
+	Providers *provider.Registry
+	Config    *config.Config
+	Model     string
+	Logger    *slog.Logger
 }
@@ -0,0 +1,15 @@
+package gateway
+
+import (
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// OutgoingHeaderMatcher returns the retry-after header of rate limited calls
+// as the standard Retry-After HTTP header. Other metadata keeps the default
+// Grpc-Metadata- prefix.
+func OutgoingHeaderMatcher(key string) (string, bool) {
+	if key == "retry-after" {
+		return "Retry-After", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
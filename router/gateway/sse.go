@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// EventStreamMIME is the content type used for server-streaming RPCs.
+const EventStreamMIME = "text/event-stream"
+
+// SSEMarshaler writes each message of a server stream as a Server-Sent
+// Events "data:" frame. Request bodies are still decoded as JSON.
+type SSEMarshaler struct {
+	runtime.JSONPb
+}
+
+func NewSSEMarshaler() *SSEMarshaler {
+	return &SSEMarshaler{
+		JSONPb: runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		},
+	}
+}
+
+func (m *SSEMarshaler) Marshal(v any) ([]byte, error) {
+	data, err := m.JSONPb.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("data: "), data...), nil
+}
+
+func (m *SSEMarshaler) ContentType(_ any) string {
+	return EventStreamMIME
+}
+
+func (m *SSEMarshaler) Delimiter() []byte {
+	return []byte("\n\n")
+}
+
+// WithEventStream makes streaming endpoints (":stream" and ":streamGenerate"
+// verbs) answer with text/event-stream regardless of the Accept header sent
+// by the client.
+func WithEventStream(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, ":stream") {
+			r.Header.Set("Accept", EventStreamMIME)
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("X-Accel-Buffering", "no")
+		}
+		h.ServeHTTP(w, r)
+	})
+}
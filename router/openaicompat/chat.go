@@ -0,0 +1,507 @@
+package openaicompat
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	v1pb "github.com/imrany/wrapper/proto/gen/api/v1"
+	apiv1 "github.com/imrany/wrapper/router/api/v1"
+)
+
+// maxBodyBytes bounds request bodies to what the gRPC server accepts, as
+// they are read in full before the call authenticates the client.
+const maxBodyBytes = 2 * apiv1.MaxInlineBytes
+
+// Handler serves the OpenAI Chat Completions wire format on top of
+// AiService, so any OpenAI SDK can talk to every provider of the wrapper.
+type Handler struct {
+	Client v1pb.AiServiceClient
+	Logger *slog.Logger
+}
+
+type chatCompletion struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []chatChoice `json:"choices"`
+	Usage   *usage       `json:"usage,omitempty"`
+}
+
+type chatChoice struct {
+	Index        int          `json:"index"`
+	Message      *chatMessage `json:"message,omitempty"`
+	Delta        *chatMessage `json:"delta,omitempty"`
+	FinishReason *string      `json:"finish_reason"`
+}
+
+type chatMessage struct {
+	Role      string            `json:"role,omitempty"`
+	Content   string            `json:"content"`
+	ToolCalls []openai.ToolCall `json:"tool_calls,omitempty"`
+}
+
+type samplingParams struct {
+	Temperature *float32 `json:"temperature"`
+	TopP        *float32 `json:"top_p"`
+}
+
+type usage struct {
+	PromptTokens     int32 `json:"prompt_tokens"`
+	CompletionTokens int32 `json:"completion_tokens"`
+	TotalTokens      int32 `json:"total_tokens"`
+}
+
+// ChatCompletions handles POST /v1/chat/completions.
+func (h *Handler) ChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "invalid_request_error", fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("failed to read request body: %v", err))
+		return
+	}
+
+	// go-openai drops zero sampling values, decode them separately so an
+	// explicit "temperature": 0 is honoured.
+	var req openai.ChatCompletionRequest
+	var sampling samplingParams
+	if err := json.Unmarshal(body, &req); err == nil {
+		err = json.Unmarshal(body, &sampling)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	genReq, err := toGenAiRequest(&req, sampling)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	if req.Stream {
+		h.stream(w, r, &req, genReq)
+		return
+	}
+
+	resp, err := h.Client.GenAi(outgoingContext(r), genReq)
+	if err != nil {
+		h.writeStatusError(w, err)
+		return
+	}
+	toolCalls, err := toOpenAIToolCalls(resp.ToolCalls, false)
+	if err != nil {
+		h.writeStatusError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, chatCompletion{
+		ID:      completionID(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   responseModel(resp.ModelVersion, resp.Model),
+		Choices: []chatChoice{{
+			Message: &chatMessage{
+				Role:      openai.ChatMessageRoleAssistant,
+				Content:   resp.Response,
+				ToolCalls: toolCalls,
+			},
+			FinishReason: finishReason(resp.FinishReason),
+		}},
+		Usage: toUsage(resp.Usage),
+	})
+}
+
+func (h *Handler) stream(w http.ResponseWriter, r *http.Request, req *openai.ChatCompletionRequest, genReq *v1pb.GenAiRequest) {
+	stream, err := h.Client.GenAiStream(outgoingContext(r), genReq)
+	if err != nil {
+		h.writeStatusError(w, err)
+		return
+	}
+
+	// Errors are only reported as HTTP errors until the first chunk arrives.
+	msg, err := stream.Recv()
+	if err != nil {
+		h.writeStatusError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+
+	chunk := chatCompletion{
+		ID:      completionID(),
+		Object:  "chat.completion.chunk",
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+	}
+	send := func(v any) bool {
+		data, err := json.Marshal(v)
+		if err != nil {
+			h.Logger.Error("Failed to marshal chunk", "error", err)
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	role := openai.ChatMessageRoleAssistant
+	for {
+		if msg.Done {
+			toolCalls, err := toOpenAIToolCalls(msg.ToolCalls, true)
+			if err != nil {
+				h.Logger.Error("Chat completion stream failed", "error", err)
+				send(errorBody("api_error", err.Error()))
+				return
+			}
+			chunk.Model = responseModel(msg.ModelVersion, msg.Model)
+			chunk.Choices = []chatChoice{{
+				Delta:        &chatMessage{ToolCalls: toolCalls},
+				FinishReason: finishReason(msg.FinishReason),
+			}}
+			if !send(chunk) {
+				return
+			}
+			if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+				chunk.Choices = []chatChoice{}
+				chunk.Usage = toUsage(msg.Usage)
+				if !send(chunk) {
+					return
+				}
+			}
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			rc.Flush()
+			return
+		}
+
+		chunk.Choices = []chatChoice{{
+			Delta: &chatMessage{Role: role, Content: msg.Delta},
+		}}
+		role = ""
+		if !send(chunk) {
+			return
+		}
+
+		msg, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			h.Logger.Error("Chat completion stream failed", "error", err)
+			s := status.Convert(err)
+			send(errorBody(errorType(s.Code()), s.Message()))
+			return
+		}
+	}
+}
+
+// toGenAiRequest maps an OpenAI chat completion request onto GenAiRequest.
+func toGenAiRequest(req *openai.ChatCompletionRequest, sampling samplingParams) (*v1pb.GenAiRequest, error) {
+	if len(req.Messages) == 0 {
+		return nil, errors.New("messages cannot be empty")
+	}
+
+	out := &v1pb.GenAiRequest{
+		Model:            req.Model,
+		GenerationConfig: &v1pb.GenerationConfig{StopSequences: req.Stop},
+	}
+	for i, m := range req.Messages {
+		role := m.Role
+		if role == openai.ChatMessageRoleDeveloper {
+			role = openai.ChatMessageRoleSystem
+		}
+
+		message := &v1pb.Message{Role: role, Content: m.Content, ToolCallId: m.ToolCallID}
+		for j, call := range m.ToolCalls {
+			args := &structpb.Struct{}
+			if call.Function.Arguments != "" {
+				if err := protojson.Unmarshal([]byte(call.Function.Arguments), args); err != nil {
+					return nil, fmt.Errorf("messages[%d].tool_calls[%d]: arguments must be a JSON object: %w", i, j, err)
+				}
+			}
+			message.ToolCalls = append(message.ToolCalls, &v1pb.ToolCall{
+				Id:        call.ID,
+				Name:      call.Function.Name,
+				Arguments: args,
+			})
+		}
+		if len(m.MultiContent) > 0 {
+			var texts []string
+			for _, part := range m.MultiContent {
+				switch part.Type {
+				case openai.ChatMessagePartTypeText:
+					texts = append(texts, part.Text)
+				case openai.ChatMessagePartTypeImageURL:
+					if part.ImageURL == nil {
+						return nil, fmt.Errorf("messages[%d]: image_url part without image_url", i)
+					}
+					image, err := toImagePart(part.ImageURL.URL)
+					if err != nil {
+						return nil, fmt.Errorf("messages[%d]: %w", i, err)
+					}
+					message.Parts = append(message.Parts, image)
+				default:
+					return nil, fmt.Errorf("messages[%d]: unsupported content part type %q", i, part.Type)
+				}
+			}
+			message.Content = strings.Join(texts, "\n")
+		}
+
+		out.Messages = append(out.Messages, message)
+	}
+
+	tools, err := toTools(req)
+	if err != nil {
+		return nil, err
+	}
+	out.Tools = tools
+	if out.ResponseFormat, err = toResponseFormat(req.ResponseFormat); err != nil {
+		return nil, err
+	}
+
+	gen := out.GenerationConfig
+	gen.Temperature = sampling.Temperature
+	gen.TopP = sampling.TopP
+	if maxTokens := max(req.MaxCompletionTokens, req.MaxTokens); maxTokens > 0 {
+		n := int32(maxTokens)
+		gen.MaxOutputTokens = &n
+	}
+	if req.Seed != nil {
+		seed := int64(*req.Seed)
+		gen.Seed = &seed
+	}
+	return out, nil
+}
+
+// toTools maps the function tools of req. A tool_choice other than "auto"
+// or "none" cannot be expressed by every provider and is rejected.
+func toTools(req *openai.ChatCompletionRequest) ([]*v1pb.Tool, error) {
+	switch req.ToolChoice {
+	case nil, "auto":
+	case "none":
+		return nil, nil
+	default:
+		return nil, errors.New(`tool_choice must be "auto" or "none"`)
+	}
+
+	tools := make([]*v1pb.Tool, 0, len(req.Tools))
+	for i, t := range req.Tools {
+		if t.Type != openai.ToolTypeFunction || t.Function == nil {
+			return nil, fmt.Errorf("tools[%d]: only function tools are supported", i)
+		}
+		tool := &v1pb.Tool{Name: t.Function.Name, Description: t.Function.Description}
+		if t.Function.Parameters != nil {
+			params, ok := t.Function.Parameters.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("tools[%d]: parameters must be a JSON object", i)
+			}
+			var err error
+			if tool.Parameters, err = structpb.NewStruct(params); err != nil {
+				return nil, fmt.Errorf("tools[%d]: invalid parameters: %w", i, err)
+			}
+		}
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
+
+// toResponseFormat maps OpenAI's response_format, with the schema decoded
+// into a Struct.
+func toResponseFormat(format *openai.ChatCompletionResponseFormat) (*v1pb.ResponseFormat, error) {
+	if format == nil {
+		return nil, nil
+	}
+	out := &v1pb.ResponseFormat{Type: string(format.Type)}
+	if schema := format.JSONSchema; schema != nil {
+		out.Name = schema.Name
+		if schema.Schema != nil {
+			data, err := schema.Schema.MarshalJSON()
+			if err != nil {
+				return nil, fmt.Errorf("response_format: invalid schema: %w", err)
+			}
+			out.Schema = &structpb.Struct{}
+			if err := protojson.Unmarshal(data, out.Schema); err != nil {
+				return nil, fmt.Errorf("response_format: schema must be a JSON object: %w", err)
+			}
+		}
+	}
+	return out, nil
+}
+
+// toOpenAIToolCalls maps tool calls onto OpenAI's, with the arguments as a
+// JSON string. Stream deltas also carry the index of each call.
+func toOpenAIToolCalls(calls []*v1pb.ToolCall, indexed bool) ([]openai.ToolCall, error) {
+	out := make([]openai.ToolCall, 0, len(calls))
+	for i, call := range calls {
+		args, err := json.Marshal(call.Arguments.AsMap())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "tool call %s: %v", call.Name, err)
+		}
+		toolCall := openai.ToolCall{
+			ID:       call.Id,
+			Type:     openai.ToolTypeFunction,
+			Function: openai.FunctionCall{Name: call.Name, Arguments: string(args)},
+		}
+		if indexed {
+			toolCall.Index = &i
+		}
+		out = append(out, toolCall)
+	}
+	return out, nil
+}
+
+// toImagePart maps an image_url, either a base64 data URL or a link, to a
+// part. Links carry no media type, so it is taken from the file extension.
+func toImagePart(url string) (*v1pb.Part, error) {
+	if rest, ok := strings.CutPrefix(url, "data:"); ok {
+		mimeType, data, ok := strings.Cut(rest, ";base64,")
+		if !ok {
+			return nil, errors.New("image data url must be base64 encoded")
+		}
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image data url: %w", err)
+		}
+		return &v1pb.Part{MimeType: mimeType, Source: &v1pb.Part_Data{Data: decoded}}, nil
+	}
+
+	mimeType, _, _ := strings.Cut(mime.TypeByExtension(path.Ext(strings.SplitN(url, "?", 2)[0])), ";")
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = "image/jpeg"
+	}
+	return &v1pb.Part{MimeType: mimeType, Source: &v1pb.Part_Url{Url: url}}, nil
+}
+
+// outgoingContext forwards the client's Authorization header, which OpenAI
+// SDKs send with their api_key, and its address to the gRPC server, like
+// the REST gateway does.
+func outgoingContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if header := r.Header.Get("Authorization"); header != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", header)
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			host = forwarded + ", " + host
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-forwarded-for", host)
+	}
+	return ctx
+}
+
+func toUsage(u *v1pb.Usage) *usage {
+	return &usage{
+		PromptTokens:     u.GetPromptTokens(),
+		CompletionTokens: u.GetCompletionTokens(),
+		TotalTokens:      u.GetTotalTokens(),
+	}
+}
+
+// finishReason maps the wrapper's finish reasons onto OpenAI's.
+func finishReason(reason string) *string {
+	switch reason {
+	case "":
+		return nil
+	case "length", "tool_calls":
+	case "safety":
+		reason = "content_filter"
+	default:
+		reason = "stop"
+	}
+	return &reason
+}
+
+func responseModel(version, model string) string {
+	if version != "" {
+		return version
+	}
+	return model
+}
+
+func completionID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "chatcmpl-" + hex.EncodeToString(b)
+}
+
+func (h *Handler) writeStatusError(w http.ResponseWriter, err error) {
+	s := status.Convert(err)
+	if s.Code() == codes.Internal || s.Code() == codes.Unknown {
+		h.Logger.Error("Chat completion failed", "error", err)
+	}
+	for _, detail := range s.Details() {
+		if retry, ok := detail.(*errdetails.RetryInfo); ok {
+			seconds := math.Ceil(retry.GetRetryDelay().AsDuration().Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+		}
+	}
+	writeError(w, runtime.HTTPStatusFromCode(s.Code()), errorType(s.Code()), s.Message())
+}
+
+func errorType(code codes.Code) string {
+	switch code {
+	case codes.InvalidArgument, codes.NotFound, codes.FailedPrecondition, codes.OutOfRange:
+		return "invalid_request_error"
+	case codes.Unauthenticated:
+		return "authentication_error"
+	case codes.PermissionDenied:
+		return "permission_error"
+	case codes.ResourceExhausted:
+		return "rate_limit_error"
+	default:
+		return "api_error"
+	}
+}
+
+func errorBody(errType, message string) openai.ErrorResponse {
+	return openai.ErrorResponse{Error: &openai.APIError{Type: errType, Message: message}}
+}
+
+func writeError(w http.ResponseWriter, code int, errType, message string) {
+	writeJSON(w, code, errorBody(errType, message))
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}